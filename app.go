@@ -2,18 +2,29 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"strconv"
 	"time"
 
 	"AzanAlarm/internal/models"
 	"AzanAlarm/internal/services"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
+// missedGracePeriod is how long after its instant an alarm may still be
+// reported as fired before it is considered missed.
+const missedGracePeriod = 2 * time.Minute
+
+// wakeCheckInterval is how often the app checks for a suspended system.
+const wakeCheckInterval = 30 * time.Second
+
 // App struct
 type App struct {
 	ctx context.Context
 
+	dataDirOverride string // From the -data-dir flag, empty if not given
+	dataDirSource   string // Where the data directory was taken from
+
 	// Services
 	storage          *services.StorageService
 	prayerCalculator *services.PrayerCalculator
@@ -21,11 +32,22 @@ type App struct {
 	alarmService     *services.AlarmService
 	settingsService  *services.SettingsService
 	qiblaService     *services.QiblaService
+	scheduleService  *services.ScheduleService
+	alarmEvents      *services.AlarmEventService
+	prayerLog        *services.PrayerLogService
+	qadaService      *services.QadaService
+	profileService   *services.ProfileService
+	mosqueService    *services.MosqueService
+	timetables       *services.TimetableService
+	backupService    *services.BackupService
+	syncService      *services.SyncService
+	apiServer        *services.APIServer
 }
 
-// NewApp creates a new App application struct
-func NewApp() *App {
-	return &App{}
+// NewApp creates a new App application struct. dataDir overrides the data
+// directory if not empty
+func NewApp(dataDir string) *App {
+	return &App{dataDirOverride: dataDir}
 }
 
 // startup is called when the app starts. The context is saved
@@ -34,105 +56,281 @@ func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
 
 	// Initialize services
-	var err error
-	a.storage, err = services.NewStorageService()
+	dataDir, source, err := services.ResolveDataDir(a.dataDirOverride)
+	if err == nil {
+		a.dataDirSource = source
+		a.storage, err = services.NewStorageService(dataDir)
+	}
 	if err != nil {
-		fmt.Println("Error initializing storage:", err)
+		// Keep running so bindings can report the problem to the UI
+		runtime.LogErrorf(ctx, "Cannot open storage: %v", err)
+		a.storage = services.NewUnavailableStorage(err)
 	}
 
-	a.prayerCalculator = services.NewPrayerCalculator()
-	a.locationService = services.NewLocationService(a.storage)
-	a.alarmService = services.NewAlarmService(a.storage)
-	a.settingsService = services.NewSettingsService(a.storage)
-	a.qiblaService = services.NewQiblaService()
-}
+	core := services.NewCore(a.storage)
+	a.prayerCalculator = core.Calculator
+	a.locationService = core.Locations
+	a.alarmService = core.Alarms
+	a.settingsService = core.Settings
+	a.qiblaService = core.Qibla
+	a.mosqueService = core.Mosques
+	a.timetables = core.Timetables
+	a.scheduleService = core.Schedule
+	a.alarmEvents = core.AlarmEvents
+	a.profileService = core.Profiles
+	a.prayerLog = core.PrayerLog
+	a.qadaService = core.Qada
+	a.backupService = core.Backup
+	a.syncService = core.Sync
+	if _, err := a.qadaService.SyncFromPrayerLog(); err != nil {
+		runtime.LogErrorf(ctx, "Cannot sync qada ledger: %v", err)
+	}
+	a.apiServer = services.NewAPIServer(
+		a.settingsService,
+		a.scheduleService,
+		a.alarmService,
+		a.profileService,
+		a.locationService,
+	)
+	a.apiServer.OnChange(func(key string) {
+		runtime.EventsEmit(a.ctx, "storage:changed", models.StorageChange{Key: key})
+	})
+	a.apiServer.OnError(func(err error) {
+		runtime.LogError(a.ctx, err.Error())
+	})
+	if err := a.apiServer.Apply(); err != nil {
+		runtime.LogErrorf(ctx, "Cannot start API: %v", err)
+	}
 
-// ============================================================
-// Prayer Times Methods
-// ============================================================
+	go a.watchForWake(ctx)
+	go a.watchStorage(ctx)
+}
 
-// GetPrayerTimes returns prayer times for a specific date
-func (a *App) GetPrayerTimes(dateStr string) models.PrayerTimes {
-	location := a.locationService.GetCurrentLocation()
-	if location == nil {
-		return models.PrayerTimes{}
+// shutdown is called when the app is closing
+func (a *App) shutdown(ctx context.Context) {
+	a.apiServer.Stop()
+	if err := a.storage.Close(); err != nil {
+		runtime.LogErrorf(ctx, "Cannot close storage: %v", err)
 	}
+}
 
-	settings := a.settingsService.GetSettings()
+// formatError converts errors returned from bindings into AppError values,
+// so the frontend receives a code and a message instead of a bare string
+func formatError(err error) any {
+	return models.AsAppError(err)
+}
 
-	// Parse date in local timezone
+// errNoLocation is returned by bindings that need a current location
+func errNoLocation() error {
+	return models.NotConfiguredError("no location set")
+}
+
+// parseDate parses a YYYY-MM-DD date in the local timezone
+func parseDate(dateStr string) (time.Time, error) {
 	date, err := time.ParseInLocation("2006-01-02", dateStr, time.Local)
 	if err != nil {
-		date = time.Now()
+		return time.Time{}, models.ValidationError("invalid date %q, expected YYYY-MM-DD", dateStr)
 	}
+	return date, nil
+}
 
-	// Get timezone offset
-	_, offset := time.Now().Zone()
-	timezoneOffset := float64(offset) / 3600.0
+// domReady is called after the frontend has loaded, so events emitted
+// from here reach the UI
+func (a *App) domReady(ctx context.Context) {
+	a.reportStorageWarnings()
+	a.syncDevices()
+	a.applyAutomaticProfiles()
+	a.checkMissedAlarms()
+}
 
-	return a.prayerCalculator.Calculate(
-		location.Latitude,
-		location.Longitude,
-		date,
-		settings.CalculationMethod,
-		settings.JuristicMethod,
-		timezoneOffset,
-	)
+// watchForWake detects system sleep by looking for jumps in wall-clock time
+// between ticks, and checks for missed alarms after waking up
+func (a *App) watchForWake(ctx context.Context) {
+	ticker := time.NewTicker(wakeCheckInterval)
+	defer ticker.Stop()
+
+	// Strip the monotonic reading, it does not advance during sleep
+	last := time.Now().Round(0)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now().Round(0)
+			if now.Sub(last) > 2*wakeCheckInterval {
+				runtime.EventsEmit(a.ctx, "system:wake")
+			}
+			last = now
+			a.reportStorageWarnings()
+			a.syncDevices()
+			a.applyAutomaticProfiles()
+			a.checkMissedAlarms()
+		}
+	}
 }
 
-// GetTodayPrayerTimes returns prayer times for today
-func (a *App) GetTodayPrayerTimes() models.PrayerTimes {
-	return a.GetPrayerTimes(time.Now().Format("2006-01-02"))
+// watchStorage reloads data files changed by other programs, such as a text
+// editor or a sync tool, and tells the UI to refresh
+func (a *App) watchStorage(ctx context.Context) {
+	watcher, err := services.NewStorageWatcher(a.storage)
+	if err != nil {
+		runtime.LogInfof(a.ctx, "Not watching data directory: %v", err)
+		return
+	}
+	watcher.OnChange("settings", a.reloadSettings)
+	watcher.OnChange("alarms", a.alarmService.Reload)
+	watcher.OnChange("alarm_profiles", a.profileService.Reload)
+	watcher.OnChange("mosques", a.mosqueService.Reload)
+	watcher.OnChange("prayer_log", a.prayerLog.Reload)
+	watcher.OnChange("qada", a.qadaService.Reload)
+	watcher.OnChange("profile_auto", a.profileService.ReloadAutoState)
+	watcher.OnChange("alarm_events", a.alarmEvents.Reload)
+	watcher.OnChange("alarm_check", a.alarmEvents.ReloadCheckState)
+	watcher.OnChange("timetables", a.timetables.Reload)
+	watcher.OnChange("sync_state", a.syncService.Reload)
+	// Locations are read from storage on every call
+	watcher.OnChange("current_location", nil)
+	watcher.OnChange("saved_locations", nil)
+
+	watcher.Run(ctx, func(change models.StorageChange) {
+		if change.Error != "" {
+			runtime.LogErrorf(a.ctx, "Cannot reload %s: %s", change.Key, change.Error)
+			runtime.EventsEmit(a.ctx, "storage:reload-failed", change)
+			return
+		}
+		runtime.EventsEmit(a.ctx, "storage:changed", change)
+	})
 }
 
-// GetNextPrayer returns the next prayer and time until it
-func (a *App) GetNextPrayer() map[string]interface{} {
-	times := a.GetTodayPrayerTimes()
-	now := time.Now()
+// reloadSettings reloads the settings from storage and starts or stops the
+// local API to match them
+func (a *App) reloadSettings() error {
+	if err := a.settingsService.Reload(); err != nil {
+		return err
+	}
+	return a.apiServer.Apply()
+}
 
-	prayers := []struct {
-		name string
-		time string
-	}{
-		{"fajr", times.Fajr},
-		{"dhuhr", times.Dhuhr},
-		{"asr", times.Asr},
-		{"maghrib", times.Maghrib},
-		{"isha", times.Isha},
+// reportStorageWarnings logs and tells the UI about data files that were
+// damaged and recovered from their backups, or could not be loaded at all,
+// and about storage that could not be opened
+func (a *App) reportStorageWarnings() {
+	for _, warning := range a.storage.Warnings() {
+		runtime.LogWarning(a.ctx, warning.Message)
+		runtime.EventsEmit(a.ctx, "storage:recovered", warning)
 	}
+}
 
-	for _, p := range prayers {
-		if p.time == "" {
-			continue
-		}
-		prayerTime, err := time.Parse(time.RFC3339, p.time)
-		if err != nil {
-			continue
-		}
-		if prayerTime.After(now) {
-			remaining := prayerTime.Sub(now)
-			return map[string]interface{}{
-				"prayer":           p.name,
-				"time":             p.time,
-				"remainingSeconds": int(remaining.Seconds()),
+// syncDevices merges alarms and saved locations with other devices if a
+// sync folder is set, and tells the UI to reload when anything changed
+func (a *App) syncDevices() {
+	if !a.syncService.IsConfigured() {
+		return
+	}
+	result, err := a.syncService.Sync(time.Now())
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Cannot sync: %v", err)
+		return
+	}
+	if result.Changed() {
+		runtime.EventsEmit(a.ctx, "sync:completed", result)
+	}
+}
+
+// applyAutomaticProfiles activates the alarm profile matching the current
+// weekday, location and Hijri month, and tells the UI to reload alarms
+func (a *App) applyAutomaticProfiles() {
+	profile, err := a.profileService.ApplyAutomatic(time.Now())
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Cannot activate alarm profile: %v", err)
+		return
+	}
+	if profile != nil {
+		runtime.EventsEmit(a.ctx, "profiles:activated", *profile)
+	}
+}
+
+// checkMissedAlarms records alarms whose instants passed without any
+// activity and reports them to the UI
+func (a *App) checkMissedAlarms() {
+	until := time.Now().Add(-missedGracePeriod)
+	from, ok := a.alarmEvents.MissedCheckWindow(until)
+	if !ok {
+		if a.alarmEvents.LastCheckedAt().IsZero() {
+			if err := a.alarmEvents.MarkChecked(until); err != nil {
+				runtime.LogErrorf(a.ctx, "Cannot save alarm check state: %v", err)
 			}
 		}
+		return
 	}
 
-	// All prayers passed, get tomorrow's Fajr
-	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
-	tomorrowTimes := a.GetPrayerTimes(tomorrow)
-	if tomorrowTimes.Fajr != "" {
-		fajrTime, _ := time.Parse(time.RFC3339, tomorrowTimes.Fajr)
-		remaining := fajrTime.Sub(now)
-		return map[string]interface{}{
-			"prayer":           "fajr",
-			"time":             tomorrowTimes.Fajr,
-			"remainingSeconds": int(remaining.Seconds()),
-		}
+	occurrences := a.scheduleService.AlarmsBetween(from, until)
+	missed, err := a.alarmEvents.RecordMissed(occurrences, until)
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Cannot record missed alarms: %v", err)
+		return
 	}
+	if len(missed) > 0 {
+		runtime.EventsEmit(a.ctx, "alarms:missed", missed)
+	}
+}
 
-	return nil
+// ============================================================
+// Prayer Times Methods
+// ============================================================
+
+// GetPrayerTimes returns prayer times for a specific date (YYYY-MM-DD)
+func (a *App) GetPrayerTimes(dateStr string) (models.PrayerTimes, error) {
+	date, err := parseDate(dateStr)
+	if err != nil {
+		return models.PrayerTimes{}, err
+	}
+
+	times, ok := a.scheduleService.PrayerTimesFor(date)
+	if !ok {
+		return models.PrayerTimes{}, errNoLocation()
+	}
+	return times, nil
+}
+
+// GetSunTimes returns sunrise, sunset and night divisions for a specific date
+func (a *App) GetSunTimes(dateStr string) (models.SunTimes, error) {
+	date, err := parseDate(dateStr)
+	if err != nil {
+		return models.SunTimes{}, err
+	}
+
+	times, ok := a.scheduleService.SunTimesFor(date)
+	if !ok {
+		return models.SunTimes{}, errNoLocation()
+	}
+	return times, nil
+}
+
+// GetForbiddenWindows returns the periods of a date in which voluntary
+// prayer is disliked
+func (a *App) GetForbiddenWindows(dateStr string) ([]models.ForbiddenWindow, error) {
+	date, err := parseDate(dateStr)
+	if err != nil {
+		return nil, err
+	}
+
+	windows, ok := a.scheduleService.ForbiddenWindowsFor(date)
+	if !ok {
+		return nil, errNoLocation()
+	}
+	return windows, nil
+}
+
+// GetTodayPrayerTimes returns prayer times for today
+func (a *App) GetTodayPrayerTimes() (models.PrayerTimes, error) {
+	return a.GetPrayerTimes(time.Now().Format("2006-01-02"))
+}
+
+// GetNextPrayer returns the current prayer window and the next prayer,
+// with a reason code when no data is available
+func (a *App) GetNextPrayer() models.NextPrayer {
+	return a.scheduleService.NextPrayerAt(time.Now())
 }
 
 // ============================================================
@@ -190,7 +388,10 @@ func (a *App) UpdateAlarm(alarm models.Alarm) error {
 
 // DeleteAlarm removes an alarm
 func (a *App) DeleteAlarm(id int) error {
-	return a.alarmService.DeleteAlarm(id)
+	if err := a.alarmService.DeleteAlarm(id); err != nil {
+		return err
+	}
+	return a.profileService.RemoveAlarm(id)
 }
 
 // ToggleAlarm toggles the active state of an alarm
@@ -198,6 +399,223 @@ func (a *App) ToggleAlarm(id int, active bool) error {
 	return a.alarmService.ToggleAlarm(id, active)
 }
 
+// CreateJumuahReminders creates the preset pre-Jumu'ah reminders for
+// ghusl and reading Surah al-Kahf that do not exist yet, and returns them
+func (a *App) CreateJumuahReminders() ([]models.Alarm, error) {
+	return a.alarmService.CreateJumuahReminders()
+}
+
+// GetUpcomingAlarms returns the instants at which active alarms will ring
+// over the next number of days, sorted by time
+func (a *App) GetUpcomingAlarms(days int) []models.AlarmOccurrence {
+	return a.scheduleService.UpcomingAlarms(time.Now(), days)
+}
+
+// RecordAlarmEvent records that an alarm due at scheduledAt was fired,
+// snoozed or dismissed
+func (a *App) RecordAlarmEvent(alarmID int, eventType string, scheduledAt string) (models.AlarmEvent, error) {
+	event := models.AlarmEvent{
+		AlarmID:     alarmID,
+		Type:        models.AlarmEventType(eventType),
+		ScheduledAt: scheduledAt,
+	}
+	if alarm := a.alarmService.GetAlarm(alarmID); alarm != nil {
+		event.Prayer = alarm.Prayer
+		event.Label = alarm.DisplayLabel()
+	}
+	return a.alarmEvents.RecordEvent(event)
+}
+
+// GetAlarmEvents returns the alarm history matching the filter
+func (a *App) GetAlarmEvents(filter models.AlarmEventFilter) []models.AlarmEvent {
+	return a.alarmEvents.GetEvents(filter)
+}
+
+// ============================================================
+// Alarm Profile Methods
+// ============================================================
+
+// GetAlarmProfiles returns all alarm profiles
+func (a *App) GetAlarmProfiles() []models.AlarmProfile {
+	return a.profileService.GetProfiles()
+}
+
+// CreateAlarmProfile creates a new alarm profile
+func (a *App) CreateAlarmProfile(profile models.AlarmProfile) (models.AlarmProfile, error) {
+	return a.profileService.CreateProfile(profile)
+}
+
+// UpdateAlarmProfile updates an existing alarm profile
+func (a *App) UpdateAlarmProfile(profile models.AlarmProfile) error {
+	return a.profileService.UpdateProfile(profile)
+}
+
+// DeleteAlarmProfile removes an alarm profile
+func (a *App) DeleteAlarmProfile(id int) error {
+	return a.profileService.DeleteProfile(id)
+}
+
+// ActivateAlarmProfile switches on the alarms of a profile and switches off
+// those that only belong to other profiles
+func (a *App) ActivateAlarmProfile(id int) error {
+	return a.profileService.ActivateProfile(id)
+}
+
+// ============================================================
+// Prayer Log Methods
+// ============================================================
+
+// LogPrayer records how a prayer was performed on a date (YYYY-MM-DD)
+func (a *App) LogPrayer(date string, prayer string, status string) (models.PrayerLogEntry, error) {
+	entry, err := a.prayerLog.LogPrayer(date, models.Prayer(prayer), models.PrayerStatus(status))
+	if err != nil {
+		return models.PrayerLogEntry{}, err
+	}
+	if _, err := a.qadaService.SyncFromPrayerLog(); err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+// ClearPrayerLog removes the record for a prayer on a date
+func (a *App) ClearPrayerLog(date string, prayer string) error {
+	if err := a.prayerLog.ClearPrayer(date, models.Prayer(prayer)); err != nil {
+		return err
+	}
+	_, err := a.qadaService.SyncFromPrayerLog()
+	return err
+}
+
+// GetPrayerLog returns the prayer records between two inclusive dates
+func (a *App) GetPrayerLog(from string, to string) []models.PrayerLogEntry {
+	return a.prayerLog.GetEntries(from, to)
+}
+
+// GetPrayerStats returns streaks and per-prayer statistics for a
+// week, month or year window ending today
+func (a *App) GetPrayerStats(window string) (models.PrayerStats, error) {
+	return a.prayerLog.GetStats(models.StatsWindow(window), time.Now())
+}
+
+// ============================================================
+// Qada Methods
+// ============================================================
+
+// GetQadaLedger returns the makeup prayers owed and completed
+func (a *App) GetQadaLedger() models.QadaLedger {
+	return a.qadaService.GetLedger()
+}
+
+// AddQada adds owed makeup prayers for a single prayer
+func (a *App) AddQada(prayer string, count int) error {
+	return a.qadaService.AddOutstanding(models.Prayer(prayer), count)
+}
+
+// AddQadaPeriod adds owed makeups of every prayer for a period counted back
+// from today, and returns the number of days added
+func (a *App) AddQadaPeriod(years int, months int, days int) (int, error) {
+	return a.qadaService.AddPeriod(years, months, days, time.Now())
+}
+
+// RecordQadaMakeup records completed makeup prayers
+func (a *App) RecordQadaMakeup(prayer string, count int) error {
+	return a.qadaService.RecordMakeup(models.Prayer(prayer), count)
+}
+
+// SetQadaDailyGoal sets how many makeups of each prayer are planned per day
+func (a *App) SetQadaDailyGoal(goal int) error {
+	return a.qadaService.SetDailyGoal(goal)
+}
+
+// GetQadaProjection estimates when the owed prayers will be made up
+func (a *App) GetQadaProjection() models.QadaProjection {
+	return a.qadaService.GetProjection(time.Now())
+}
+
+// ============================================================
+// Mosque Methods
+// ============================================================
+
+// GetMosques returns all mosques
+func (a *App) GetMosques() []models.Mosque {
+	return a.mosqueService.GetMosques()
+}
+
+// CreateMosque creates a new mosque with its iqamah schedule
+func (a *App) CreateMosque(mosque models.Mosque) (models.Mosque, error) {
+	return a.mosqueService.CreateMosque(mosque)
+}
+
+// UpdateMosque updates an existing mosque
+func (a *App) UpdateMosque(mosque models.Mosque) error {
+	return a.mosqueService.UpdateMosque(mosque)
+}
+
+// DeleteMosque removes a mosque
+func (a *App) DeleteMosque(id int) error {
+	return a.mosqueService.DeleteMosque(id)
+}
+
+// SetDefaultMosque sets the mosque used for the iqamah countdown
+func (a *App) SetDefaultMosque(id int) error {
+	return a.mosqueService.SetDefaultMosque(id)
+}
+
+// ============================================================
+// Timetable Methods
+// ============================================================
+
+// ChooseTimetableFile opens a file dialog to pick a timetable CSV file
+func (a *App) ChooseTimetableFile() (string, error) {
+	return runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import Timetable",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "CSV files (*.csv)", Pattern: "*.csv"},
+		},
+	})
+}
+
+// GetDefaultTimetableMapping returns the column mapping for a CSV file with
+// a header row named after the prayers
+func (a *App) GetDefaultTimetableMapping() models.TimetableMapping {
+	return models.DefaultTimetableMapping()
+}
+
+// PreviewTimetable reads a timetable file and compares it with calculated
+// times for the current location without importing it
+func (a *App) PreviewTimetable(path string, mapping models.TimetableMapping) (models.TimetableImportResult, error) {
+	location := a.locationService.GetCurrentLocation()
+	if location == nil {
+		return models.TimetableImportResult{}, errNoLocation()
+	}
+	return a.timetables.Preview(path, mapping, *location)
+}
+
+// ImportTimetable imports a timetable file for the current location
+func (a *App) ImportTimetable(path string, name string, mapping models.TimetableMapping) (models.TimetableImportResult, error) {
+	location := a.locationService.GetCurrentLocation()
+	if location == nil {
+		return models.TimetableImportResult{}, errNoLocation()
+	}
+	return a.timetables.Import(path, name, mapping, *location)
+}
+
+// GetTimetables returns all imported timetables
+func (a *App) GetTimetables() []models.Timetable {
+	return a.timetables.GetTimetables()
+}
+
+// CompareCalculationMethods ranks every calculation method and juristic
+// school by how closely they match an imported timetable
+func (a *App) CompareCalculationMethods(timetableID int) (models.MethodComparisonReport, error) {
+	return a.timetables.CompareMethods(timetableID)
+}
+
+// DeleteTimetable removes an imported timetable
+func (a *App) DeleteTimetable(id int) error {
+	return a.timetables.DeleteTimetable(id)
+}
+
 // ============================================================
 // Settings Methods
 // ============================================================
@@ -207,14 +625,37 @@ func (a *App) GetSettings() models.AppSettings {
 	return a.settingsService.GetSettings()
 }
 
-// SaveSettings saves the application settings
+// SaveSettings saves the application settings and starts or stops the
+// local API to match them
 func (a *App) SaveSettings(settings models.AppSettings) error {
-	return a.settingsService.SaveSettings(settings)
+	if err := a.settingsService.SaveSettings(settings); err != nil {
+		return err
+	}
+	return a.apiServer.Apply()
 }
 
 // ResetSettingsToDefaults resets settings to defaults
 func (a *App) ResetSettingsToDefaults() error {
-	return a.settingsService.ResetToDefaults()
+	if err := a.settingsService.ResetToDefaults(); err != nil {
+		return err
+	}
+	return a.apiServer.Apply()
+}
+
+// ============================================================
+// API Methods
+// ============================================================
+
+// GetAPIAddress returns the address the local API listens on, or an empty
+// string if it is not running
+func (a *App) GetAPIAddress() string {
+	return a.apiServer.Address()
+}
+
+// RegenerateAPIToken replaces the local API token, so clients using the old
+// one are refused
+func (a *App) RegenerateAPIToken() (string, error) {
+	return a.settingsService.RegenerateAPIToken()
 }
 
 // ============================================================
@@ -222,21 +663,92 @@ func (a *App) ResetSettingsToDefaults() error {
 // ============================================================
 
 // GetQiblaDirection returns the Qibla direction from current location
-func (a *App) GetQiblaDirection() float64 {
+func (a *App) GetQiblaDirection() (float64, error) {
 	location := a.locationService.GetCurrentLocation()
 	if location == nil {
-		return 0
+		return 0, errNoLocation()
 	}
-	return a.qiblaService.GetQiblaDirection(location.Latitude, location.Longitude)
+	return a.qiblaService.GetQiblaDirection(location.Latitude, location.Longitude), nil
 }
 
 // GetDistanceToMakkah returns distance to Makkah in kilometers
-func (a *App) GetDistanceToMakkah() float64 {
+func (a *App) GetDistanceToMakkah() (float64, error) {
 	location := a.locationService.GetCurrentLocation()
 	if location == nil {
-		return 0
+		return 0, errNoLocation()
 	}
-	return a.qiblaService.GetDistanceToMakkah(location.Latitude, location.Longitude)
+	return a.qiblaService.GetDistanceToMakkah(location.Latitude, location.Longitude), nil
+}
+
+// ============================================================
+// Backup Methods
+// ============================================================
+
+// ExportBackup writes settings, locations, mosques, alarms, profiles,
+// timetables, logs and the qada ledger to a single backup file
+func (a *App) ExportBackup(path string) error {
+	return a.backupService.Export(path)
+}
+
+// ImportBackup restores a backup file. Mode is "merge" to add what is
+// missing or "replace" to discard the current data
+func (a *App) ImportBackup(path string, mode string) (models.RestoreSummary, error) {
+	return a.backupService.Import(path, models.RestoreMode(mode))
+}
+
+// ============================================================
+// Sync Methods
+// ============================================================
+
+// SyncNow merges alarms and saved locations with the other devices using
+// the sync folder
+func (a *App) SyncNow() (models.SyncResult, error) {
+	return a.syncService.Sync(time.Now())
+}
+
+// ChooseSyncFolder opens a dialog to pick the shared sync folder
+func (a *App) ChooseSyncFolder() (string, error) {
+	return runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:                "Choose Sync Folder",
+		CanCreateDirectories: true,
+	})
+}
+
+// ============================================================
+// Storage Methods
+// ============================================================
+
+// GetStorageInfo returns the storage backend in use
+func (a *App) GetStorageInfo() models.StorageInfo {
+	return models.StorageInfo{
+		Backend:         a.storage.BackendName(),
+		DataDir:         a.storage.GetDataDir(),
+		DataDirSource:   a.dataDirSource,
+		SQLiteAvailable: services.SQLiteAvailable,
+	}
+}
+
+// MigrateStorage copies all data to another backend ("json" or "sqlite")
+// and switches to it. Returns the number of data keys copied, keys that
+// could not be read are reported as storage warnings
+func (a *App) MigrateStorage(backend string) (int, error) {
+	copied, err := a.storage.MigrateTo(backend)
+	if err != nil {
+		return 0, err
+	}
+	a.reportStorageWarnings()
+	return copied, nil
+}
+
+// DiscardUnreadableData sets aside data that could not be loaded, e.g. a
+// damaged file or one from a newer version, so the key can be saved again.
+// The data is kept next to the data files with an ".unreadable" suffix
+func (a *App) DiscardUnreadableData(key string) error {
+	if err := a.storage.Discard(key); err != nil {
+		return err
+	}
+	runtime.EventsEmit(a.ctx, "storage:changed", models.StorageChange{Key: key})
+	return nil
 }
 
 // ============================================================
@@ -0,0 +1,24 @@
+// Command azanalarmd is AzanAlarm without the desktop window, for machines
+// that have no webview, such as headless home servers. It runs the daemon
+// by default and accepts the same commands as the desktop binary.
+package main
+
+import (
+	"flag"
+	"os"
+
+	"AzanAlarm/internal/cli"
+	"AzanAlarm/internal/services"
+)
+
+func main() {
+	dataDir := flag.String("data-dir", "", "directory for application data (overrides "+services.DataDirEnv+")")
+	flag.Usage = func() { cli.Usage(flag.CommandLine.Output()) }
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"daemon"}
+	}
+	os.Exit(cli.Run(*dataDir, args, os.Stdout, os.Stderr))
+}
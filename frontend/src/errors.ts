@@ -0,0 +1,41 @@
+// Errors rejected by the Go bindings carry a code and a message.
+export type ErrorCode =
+    | 'not_configured'
+    | 'not_found'
+    | 'validation'
+    | 'storage_unavailable'
+    | 'network'
+    | 'unauthorized'
+    | 'internal'
+
+export interface FieldError {
+    field: string
+    message: string
+}
+
+export interface AppError {
+    code: ErrorCode
+    message: string
+    fields?: FieldError[]
+}
+
+export function isAppError(error: unknown): error is AppError {
+    return typeof error === 'object' && error !== null && 'code' in error && 'message' in error
+}
+
+export function errorMessage(error: unknown): string {
+    if (isAppError(error)) return error.message
+    if (error instanceof Error) return error.message
+    return String(error)
+}
+
+// fieldErrors maps each rejected field, such as 'repeatDays' or
+// 'jumuah.khutbahTime', to its first message.
+export function fieldErrors(error: unknown): Record<string, string> {
+    const result: Record<string, string> = {}
+    if (!isAppError(error) || !error.fields) return result
+    for (const f of error.fields) {
+        if (!(f.field in result)) result[f.field] = f.message
+    }
+    return result
+}
@@ -1,6 +1,7 @@
 import { defineStore } from 'pinia'
 import { ref } from 'vue'
 import { GetAlarms, CreateAlarm, UpdateAlarm, DeleteAlarm, ToggleAlarm } from '../../wailsjs/go/main/App'
+import { fieldErrors as toFieldErrors } from '../errors'
 
 interface Alarm {
     id: number
@@ -18,6 +19,7 @@ interface Alarm {
 export const useAlarmStore = defineStore('alarms', () => {
     const alarms = ref<Alarm[]>([])
     const loading = ref(false)
+    const fieldErrors = ref<Record<string, string>>({})
 
     async function loadAlarms() {
         loading.value = true
@@ -32,15 +34,18 @@ export const useAlarmStore = defineStore('alarms', () => {
     }
 
     async function createAlarm(alarm: Partial<Alarm>) {
+        fieldErrors.value = {}
         try {
             const created = await CreateAlarm(alarm as any)
             alarms.value.push(created as unknown as Alarm)
         } catch (error) {
+            fieldErrors.value = toFieldErrors(error)
             console.error('Failed to create alarm:', error)
         }
     }
 
     async function updateAlarm(alarm: Alarm) {
+        fieldErrors.value = {}
         try {
             await UpdateAlarm(alarm as any)
             const index = alarms.value.findIndex(a => a.id === alarm.id)
@@ -48,6 +53,7 @@ export const useAlarmStore = defineStore('alarms', () => {
                 alarms.value[index] = alarm
             }
         } catch (error) {
+            fieldErrors.value = toFieldErrors(error)
             console.error('Failed to update alarm:', error)
         }
     }
@@ -89,6 +95,7 @@ export const useAlarmStore = defineStore('alarms', () => {
     return {
         alarms,
         loading,
+        fieldErrors,
         loadAlarms,
         createAlarm,
         updateAlarm,
@@ -1,14 +1,21 @@
 import { defineStore } from 'pinia'
 import { ref } from 'vue'
 import { usePrayerStore } from './prayerStore'
-import { useAlarmStore } from './alarmStore'
 import { useSettingsStore } from './settingsStore'
+import { GetUpcomingAlarms, RecordAlarmEvent } from '../../wailsjs/go/main/App'
+import { models } from '../../wailsjs/go/models'
 
+// How long a snoozed alarm waits before ringing again
+const SNOOZE_MINUTES = 5
 
 export const useAudioStore = defineStore('audio', () => {
     const isPlaying = ref(false)
     const checkInterval = ref<number | null>(null)
     const lastPlayedMinute = ref<string>('') // Prevent multiple triggers in same minute
+    const upcomingAlarms = ref<models.AlarmOccurrence[]>([])
+    const ringingAlarms = ref<models.AlarmOccurrence[]>([]) // Custom alarms waiting to be snoozed or dismissed
+    let lastRefreshMinute = ''
+    let snoozeTimer: number | null = null
 
     // Web Audio Context (Lazy initialized)
     let audioContext: AudioContext | null = null
@@ -45,9 +52,11 @@ export const useAudioStore = defineStore('audio', () => {
         if (currentTimeStr === lastPlayedMinute.value) return
 
         const prayerStore = usePrayerStore()
-        const alarmStore = useAlarmStore()
         const settingsStore = useSettingsStore()
 
+        const currentFormatted = formatTimeForComparison(now)
+        let ring = false
+
         // 1. Check Prayer Times (if notifications enabled)
         if (settingsStore.settings.enableNotifications && prayerStore.todayTimes) {
             const prayers = [
@@ -58,46 +67,79 @@ export const useAudioStore = defineStore('audio', () => {
                 prayerStore.todayTimes.isha
             ]
 
-            // Generate formatted times for comparison
-            const currentFormatted = formatTimeForComparison(now)
-
             for (const pTime of prayers) {
                 const pDate = new Date(pTime)
-                const pFormatted = formatTimeForComparison(pDate)
-
-                if (currentFormatted === pFormatted) {
-                    playAudio()
-                    lastPlayedMinute.value = currentTimeStr
-                    return
+                if (formatTimeForComparison(pDate) === currentFormatted) {
+                    ring = true
                 }
             }
         }
 
-        // 2. Check Custom Alarms
-        const currentDay = now.getDay() || 7 // Convert 0(Sun) to 7
+        // 2. Check Custom Alarms (instants are resolved by the backend so
+        // every anchor type is handled the same way). Every alarm due this
+        // minute is recorded as fired, or it would later be reported missed.
+        const due: models.AlarmOccurrence[] = []
+        for (const occurrence of upcomingAlarms.value) {
+            const alarmTime = new Date(occurrence.time)
+            if (alarmTime.toDateString() !== now.toDateString()) continue
+            if (formatTimeForComparison(alarmTime) !== currentFormatted) continue
+
+            ring = true
+            due.push(occurrence)
+        }
+        recordAlarmEvents(due, 'fired')
 
-        alarmStore.alarms.forEach(alarm => {
-            if (!alarm.isActive) return
-            // If repeatDays is empty -> runs daily. Else check if today is included.
-            if (alarm.repeatDays.length > 0 && !alarm.repeatDays.includes(currentDay)) return
+        if (ring) {
+            if (due.length) {
+                ringingAlarms.value = [...ringingAlarms.value, ...due]
+            }
+            // Play once, however many alarms are due
+            playAudio()
+            lastPlayedMinute.value = currentTimeStr
+            return
+        }
+
+        if (lastRefreshMinute !== currentTimeStr) {
+            lastRefreshMinute = currentTimeStr
+            refreshUpcomingAlarms()
+        }
+    }
 
-            // Find the base prayer time for this alarm
-            const prayerTimeStr = (prayerStore.todayTimes as any)?.[alarm.prayer]
-            if (!prayerTimeStr) return
+    function recordAlarmEvents(occurrences: models.AlarmOccurrence[], type: 'fired' | 'snoozed' | 'dismissed') {
+        for (const occurrence of occurrences) {
+            RecordAlarmEvent(occurrence.alarmId, type, occurrence.time)
+                .catch(error => console.error('Failed to record alarm event:', error))
+        }
+    }
 
-            const alarmTime = new Date(prayerTimeStr)
-            // Add offset
-            alarmTime.setMinutes(alarmTime.getMinutes() + alarm.offsetMinutes)
+    // Stops the ringing alarms for good
+    function dismissAlarms() {
+        recordAlarmEvents(ringingAlarms.value, 'dismissed')
+        ringingAlarms.value = []
+        stopAudio()
+    }
 
-            const alarmFormatted = formatTimeForComparison(alarmTime)
-            const currentFormatted = formatTimeForComparison(now)
+    // Stops the ringing alarms and rings them again after SNOOZE_MINUTES
+    function snoozeAlarms() {
+        const snoozed = ringingAlarms.value
+        recordAlarmEvents(snoozed, 'snoozed')
+        ringingAlarms.value = []
+        stopAudio()
+
+        if (snoozeTimer) window.clearTimeout(snoozeTimer)
+        snoozeTimer = window.setTimeout(() => {
+            snoozeTimer = null
+            ringingAlarms.value = [...ringingAlarms.value, ...snoozed]
+            playAudio()
+        }, SNOOZE_MINUTES * 60 * 1000)
+    }
 
-            if (currentFormatted === alarmFormatted) {
-                playAudio()
-                lastPlayedMinute.value = currentTimeStr
-                return
-            }
-        })
+    async function refreshUpcomingAlarms() {
+        try {
+            upcomingAlarms.value = await GetUpcomingAlarms(2)
+        } catch (error) {
+            console.error('Failed to load upcoming alarms:', error)
+        }
     }
 
     function formatTimeForComparison(date: Date): string {
@@ -224,9 +266,12 @@ export const useAudioStore = defineStore('audio', () => {
 
     return {
         isPlaying,
+        ringingAlarms,
         init,
         playBeep,
         triggerAlarm,
-        stopAudio
+        stopAudio,
+        dismissAlarms,
+        snoozeAlarms
     }
 })
@@ -1,6 +1,7 @@
 import { defineStore } from 'pinia'
 import { ref, computed } from 'vue'
 import { GetTodayPrayerTimes, GetNextPrayer, GetPrayerTimes } from '../../wailsjs/go/main/App'
+import { models } from '../../wailsjs/go/models'
 import { useSettingsStore } from './settingsStore'
 
 interface PrayerTimes {
@@ -9,17 +10,12 @@ interface PrayerTimes {
     asr: string
     maghrib: string
     isha: string
-}
-
-interface NextPrayer {
-    prayer: string
-    time: string
-    remainingSeconds: number
+    jumuah: string
 }
 
 export const usePrayerStore = defineStore('prayer', () => {
     const todayTimes = ref<PrayerTimes | null>(null)
-    const nextPrayer = ref<NextPrayer | null>(null)
+    const nextPrayer = ref<models.NextPrayer | null>(null)
     const loading = ref(false)
     const countdown = ref<string>('')
     let countdownInterval: number | null = null
@@ -32,7 +28,9 @@ export const usePrayerStore = defineStore('prayer', () => {
 
         return [
             { key: 'fajr', name: 'Fajr', time: todayTimes.value.fajr, icon: '🌅', color: 'var(--fajr-color)' },
-            { key: 'dhuhr', name: 'Dhuhr', time: todayTimes.value.dhuhr, icon: '☀️', color: 'var(--dhuhr-color)' },
+            todayTimes.value.jumuah
+                ? { key: 'jumuah', name: "Jumu'ah", time: todayTimes.value.jumuah, icon: '🕌', color: 'var(--dhuhr-color)' }
+                : { key: 'dhuhr', name: 'Dhuhr', time: todayTimes.value.dhuhr, icon: '☀️', color: 'var(--dhuhr-color)' },
             { key: 'asr', name: 'Asr', time: todayTimes.value.asr, icon: '🌤️', color: 'var(--asr-color)' },
             { key: 'maghrib', name: 'Maghrib', time: todayTimes.value.maghrib, icon: '🌅', color: 'var(--maghrib-color)' },
             { key: 'isha', name: 'Isha', time: todayTimes.value.isha, icon: '🌙', color: 'var(--isha-color)' },
@@ -72,7 +70,7 @@ export const usePrayerStore = defineStore('prayer', () => {
     async function loadNextPrayer() {
         try {
             const next = await GetNextPrayer()
-            nextPrayer.value = next as unknown as NextPrayer
+            nextPrayer.value = next.available ? next : null
             updateCountdown()
         } catch (error) {
             console.error('Failed to load next prayer:', error)
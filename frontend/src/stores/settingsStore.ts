@@ -1,6 +1,7 @@
 import { defineStore } from 'pinia'
 import { ref } from 'vue'
 import { GetSettings, SaveSettings } from '../../wailsjs/go/main/App'
+import { fieldErrors as toFieldErrors } from '../errors'
 
 interface AppSettings {
     calculationMethod: string
@@ -11,6 +12,23 @@ interface AppSettings {
     enableVibration: boolean
     theme: string
     language: string
+    jumuah: {
+        enabled: boolean
+        khutbahTime: string
+    }
+    forbiddenWindows: {
+        sunriseMinutes: number
+        zenithMinutes: number
+        sunsetMinutes: number
+    }
+    sync: {
+        directory: string
+    }
+    api: {
+        enabled: boolean
+        address: string
+        token: string
+    }
 }
 
 export const useSettingsStore = defineStore('settings', () => {
@@ -23,9 +41,27 @@ export const useSettingsStore = defineStore('settings', () => {
         enableVibration: true,
         theme: 'system',
         language: 'en',
+        jumuah: {
+            enabled: true,
+            khutbahTime: '',
+        },
+        forbiddenWindows: {
+            sunriseMinutes: 15,
+            zenithMinutes: 10,
+            sunsetMinutes: 15,
+        },
+        sync: {
+            directory: '',
+        },
+        api: {
+            enabled: false,
+            address: '127.0.0.1:8765',
+            token: '',
+        },
     })
 
     const loading = ref(false)
+    const fieldErrors = ref<Record<string, string>>({})
 
     const theme = ref<string>('light')
 
@@ -46,11 +82,13 @@ export const useSettingsStore = defineStore('settings', () => {
 
     async function saveSettings(newSettings: Partial<AppSettings>) {
         const updated = { ...settings.value, ...newSettings }
+        fieldErrors.value = {}
         try {
             await SaveSettings(updated as any)
             settings.value = updated
             updateTheme()
         } catch (error) {
+            fieldErrors.value = toFieldErrors(error)
             console.error('Failed to save settings:', error)
         }
     }
@@ -72,6 +110,7 @@ export const useSettingsStore = defineStore('settings', () => {
     return {
         settings,
         loading,
+        fieldErrors,
         theme,
         loadSettings,
         saveSettings,
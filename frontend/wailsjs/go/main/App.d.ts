@@ -2,14 +2,50 @@
 // This file is automatically generated. DO NOT EDIT
 import {models} from '../models';
 
+export function ActivateAlarmProfile(arg1:number):Promise<void>;
+
+export function AddQada(arg1:string,arg2:number):Promise<void>;
+
+export function AddQadaPeriod(arg1:number,arg2:number,arg3:number):Promise<number>;
+
+export function ChooseSyncFolder():Promise<string>;
+
+export function ChooseTimetableFile():Promise<string>;
+
+export function ClearPrayerLog(arg1:string,arg2:string):Promise<void>;
+
+export function CompareCalculationMethods(arg1:number):Promise<models.MethodComparisonReport>;
+
 export function CreateAlarm(arg1:models.Alarm):Promise<models.Alarm>;
 
+export function CreateAlarmProfile(arg1:models.AlarmProfile):Promise<models.AlarmProfile>;
+
+export function CreateJumuahReminders():Promise<Array<models.Alarm>>;
+
+export function CreateMosque(arg1:models.Mosque):Promise<models.Mosque>;
+
 export function DeleteAlarm(arg1:number):Promise<void>;
 
+export function DeleteAlarmProfile(arg1:number):Promise<void>;
+
 export function DeleteLocation(arg1:number):Promise<void>;
 
+export function DeleteMosque(arg1:number):Promise<void>;
+
+export function DeleteTimetable(arg1:number):Promise<void>;
+
+export function DiscardUnreadableData(arg1:string):Promise<void>;
+
+export function ExportBackup(arg1:string):Promise<void>;
+
 export function FormatTime(arg1:string,arg2:boolean):Promise<string>;
 
+export function GetAPIAddress():Promise<string>;
+
+export function GetAlarmEvents(arg1:models.AlarmEventFilter):Promise<Array<models.AlarmEvent>>;
+
+export function GetAlarmProfiles():Promise<Array<models.AlarmProfile>>;
+
 export function GetAlarms():Promise<Array<models.Alarm>>;
 
 export function GetCalculationMethods():Promise<Array<Record<string, string>>>;
@@ -20,22 +56,60 @@ export function GetCurrentLocation():Promise<models.Location>;
 
 export function GetCurrentTime():Promise<string>;
 
+export function GetDefaultTimetableMapping():Promise<models.TimetableMapping>;
+
 export function GetDistanceToMakkah():Promise<number>;
 
-export function GetNextPrayer():Promise<Record<string, any>>;
+export function GetForbiddenWindows(arg1:string):Promise<Array<models.ForbiddenWindow>>;
+
+export function GetMosques():Promise<Array<models.Mosque>>;
+
+export function GetNextPrayer():Promise<models.NextPrayer>;
+
+export function GetPrayerLog(arg1:string,arg2:string):Promise<Array<models.PrayerLogEntry>>;
+
+export function GetPrayerStats(arg1:string):Promise<models.PrayerStats>;
 
 export function GetPrayerTimes(arg1:string):Promise<models.PrayerTimes>;
 
+export function GetQadaLedger():Promise<models.QadaLedger>;
+
+export function GetQadaProjection():Promise<models.QadaProjection>;
+
 export function GetQiblaDirection():Promise<number>;
 
 export function GetSavedLocations():Promise<Array<models.Location>>;
 
 export function GetSettings():Promise<models.AppSettings>;
 
+export function GetStorageInfo():Promise<models.StorageInfo>;
+
+export function GetSunTimes(arg1:string):Promise<models.SunTimes>;
+
+export function GetTimetables():Promise<Array<models.Timetable>>;
+
 export function GetTodayPrayerTimes():Promise<models.PrayerTimes>;
 
+export function GetUpcomingAlarms(arg1:number):Promise<Array<models.AlarmOccurrence>>;
+
+export function ImportBackup(arg1:string,arg2:string):Promise<models.RestoreSummary>;
+
+export function ImportTimetable(arg1:string,arg2:string,arg3:models.TimetableMapping):Promise<models.TimetableImportResult>;
+
+export function LogPrayer(arg1:string,arg2:string,arg3:string):Promise<models.PrayerLogEntry>;
+
+export function MigrateStorage(arg1:string):Promise<number>;
+
 export function ParseFloat(arg1:string):Promise<number>;
 
+export function PreviewTimetable(arg1:string,arg2:models.TimetableMapping):Promise<models.TimetableImportResult>;
+
+export function RecordAlarmEvent(arg1:number,arg2:string,arg3:string):Promise<models.AlarmEvent>;
+
+export function RecordQadaMakeup(arg1:string,arg2:number):Promise<void>;
+
+export function RegenerateAPIToken():Promise<string>;
+
 export function ResetSettingsToDefaults():Promise<void>;
 
 export function SaveLocation(arg1:models.Location):Promise<void>;
@@ -46,6 +120,16 @@ export function SearchLocations(arg1:string):Promise<Array<models.Location>>;
 
 export function SetCurrentLocation(arg1:models.Location):Promise<void>;
 
+export function SetDefaultMosque(arg1:number):Promise<void>;
+
+export function SetQadaDailyGoal(arg1:number):Promise<void>;
+
+export function SyncNow():Promise<models.SyncResult>;
+
 export function ToggleAlarm(arg1:number,arg2:boolean):Promise<void>;
 
 export function UpdateAlarm(arg1:models.Alarm):Promise<void>;
+
+export function UpdateAlarmProfile(arg1:models.AlarmProfile):Promise<void>;
+
+export function UpdateMosque(arg1:models.Mosque):Promise<void>;
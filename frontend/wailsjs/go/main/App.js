@@ -2,22 +2,94 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function ActivateAlarmProfile(arg1) {
+  return window['go']['main']['App']['ActivateAlarmProfile'](arg1);
+}
+
+export function AddQada(arg1, arg2) {
+  return window['go']['main']['App']['AddQada'](arg1, arg2);
+}
+
+export function AddQadaPeriod(arg1, arg2, arg3) {
+  return window['go']['main']['App']['AddQadaPeriod'](arg1, arg2, arg3);
+}
+
+export function ChooseSyncFolder() {
+  return window['go']['main']['App']['ChooseSyncFolder']();
+}
+
+export function ChooseTimetableFile() {
+  return window['go']['main']['App']['ChooseTimetableFile']();
+}
+
+export function ClearPrayerLog(arg1, arg2) {
+  return window['go']['main']['App']['ClearPrayerLog'](arg1, arg2);
+}
+
+export function CompareCalculationMethods(arg1) {
+  return window['go']['main']['App']['CompareCalculationMethods'](arg1);
+}
+
 export function CreateAlarm(arg1) {
   return window['go']['main']['App']['CreateAlarm'](arg1);
 }
 
+export function CreateAlarmProfile(arg1) {
+  return window['go']['main']['App']['CreateAlarmProfile'](arg1);
+}
+
+export function CreateJumuahReminders() {
+  return window['go']['main']['App']['CreateJumuahReminders']();
+}
+
+export function CreateMosque(arg1) {
+  return window['go']['main']['App']['CreateMosque'](arg1);
+}
+
 export function DeleteAlarm(arg1) {
   return window['go']['main']['App']['DeleteAlarm'](arg1);
 }
 
+export function DeleteAlarmProfile(arg1) {
+  return window['go']['main']['App']['DeleteAlarmProfile'](arg1);
+}
+
 export function DeleteLocation(arg1) {
   return window['go']['main']['App']['DeleteLocation'](arg1);
 }
 
+export function DeleteMosque(arg1) {
+  return window['go']['main']['App']['DeleteMosque'](arg1);
+}
+
+export function DeleteTimetable(arg1) {
+  return window['go']['main']['App']['DeleteTimetable'](arg1);
+}
+
+export function DiscardUnreadableData(arg1) {
+  return window['go']['main']['App']['DiscardUnreadableData'](arg1);
+}
+
+export function ExportBackup(arg1) {
+  return window['go']['main']['App']['ExportBackup'](arg1);
+}
+
 export function FormatTime(arg1, arg2) {
   return window['go']['main']['App']['FormatTime'](arg1, arg2);
 }
 
+export function GetAPIAddress() {
+  return window['go']['main']['App']['GetAPIAddress']();
+}
+
+export function GetAlarmEvents(arg1) {
+  return window['go']['main']['App']['GetAlarmEvents'](arg1);
+}
+
+export function GetAlarmProfiles() {
+  return window['go']['main']['App']['GetAlarmProfiles']();
+}
+
 export function GetAlarms() {
   return window['go']['main']['App']['GetAlarms']();
 }
@@ -38,18 +110,46 @@ export function GetCurrentTime() {
   return window['go']['main']['App']['GetCurrentTime']();
 }
 
+export function GetDefaultTimetableMapping() {
+  return window['go']['main']['App']['GetDefaultTimetableMapping']();
+}
+
 export function GetDistanceToMakkah() {
   return window['go']['main']['App']['GetDistanceToMakkah']();
 }
 
+export function GetForbiddenWindows(arg1) {
+  return window['go']['main']['App']['GetForbiddenWindows'](arg1);
+}
+
+export function GetMosques() {
+  return window['go']['main']['App']['GetMosques']();
+}
+
 export function GetNextPrayer() {
   return window['go']['main']['App']['GetNextPrayer']();
 }
 
+export function GetPrayerLog(arg1, arg2) {
+  return window['go']['main']['App']['GetPrayerLog'](arg1, arg2);
+}
+
+export function GetPrayerStats(arg1) {
+  return window['go']['main']['App']['GetPrayerStats'](arg1);
+}
+
 export function GetPrayerTimes(arg1) {
   return window['go']['main']['App']['GetPrayerTimes'](arg1);
 }
 
+export function GetQadaLedger() {
+  return window['go']['main']['App']['GetQadaLedger']();
+}
+
+export function GetQadaProjection() {
+  return window['go']['main']['App']['GetQadaProjection']();
+}
+
 export function GetQiblaDirection() {
   return window['go']['main']['App']['GetQiblaDirection']();
 }
@@ -62,14 +162,62 @@ export function GetSettings() {
   return window['go']['main']['App']['GetSettings']();
 }
 
+export function GetStorageInfo() {
+  return window['go']['main']['App']['GetStorageInfo']();
+}
+
+export function GetSunTimes(arg1) {
+  return window['go']['main']['App']['GetSunTimes'](arg1);
+}
+
+export function GetTimetables() {
+  return window['go']['main']['App']['GetTimetables']();
+}
+
 export function GetTodayPrayerTimes() {
   return window['go']['main']['App']['GetTodayPrayerTimes']();
 }
 
+export function GetUpcomingAlarms(arg1) {
+  return window['go']['main']['App']['GetUpcomingAlarms'](arg1);
+}
+
+export function ImportBackup(arg1, arg2) {
+  return window['go']['main']['App']['ImportBackup'](arg1, arg2);
+}
+
+export function ImportTimetable(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ImportTimetable'](arg1, arg2, arg3);
+}
+
+export function LogPrayer(arg1, arg2, arg3) {
+  return window['go']['main']['App']['LogPrayer'](arg1, arg2, arg3);
+}
+
+export function MigrateStorage(arg1) {
+  return window['go']['main']['App']['MigrateStorage'](arg1);
+}
+
 export function ParseFloat(arg1) {
   return window['go']['main']['App']['ParseFloat'](arg1);
 }
 
+export function PreviewTimetable(arg1, arg2) {
+  return window['go']['main']['App']['PreviewTimetable'](arg1, arg2);
+}
+
+export function RecordAlarmEvent(arg1, arg2, arg3) {
+  return window['go']['main']['App']['RecordAlarmEvent'](arg1, arg2, arg3);
+}
+
+export function RecordQadaMakeup(arg1, arg2) {
+  return window['go']['main']['App']['RecordQadaMakeup'](arg1, arg2);
+}
+
+export function RegenerateAPIToken() {
+  return window['go']['main']['App']['RegenerateAPIToken']();
+}
+
 export function ResetSettingsToDefaults() {
   return window['go']['main']['App']['ResetSettingsToDefaults']();
 }
@@ -90,6 +238,18 @@ export function SetCurrentLocation(arg1) {
   return window['go']['main']['App']['SetCurrentLocation'](arg1);
 }
 
+export function SetDefaultMosque(arg1) {
+  return window['go']['main']['App']['SetDefaultMosque'](arg1);
+}
+
+export function SetQadaDailyGoal(arg1) {
+  return window['go']['main']['App']['SetQadaDailyGoal'](arg1);
+}
+
+export function SyncNow() {
+  return window['go']['main']['App']['SyncNow']();
+}
+
 export function ToggleAlarm(arg1, arg2) {
   return window['go']['main']['App']['ToggleAlarm'](arg1, arg2);
 }
@@ -97,3 +257,11 @@ export function ToggleAlarm(arg1, arg2) {
 export function UpdateAlarm(arg1) {
   return window['go']['main']['App']['UpdateAlarm'](arg1);
 }
+
+export function UpdateAlarmProfile(arg1) {
+  return window['go']['main']['App']['UpdateAlarmProfile'](arg1);
+}
+
+export function UpdateMosque(arg1) {
+  return window['go']['main']['App']['UpdateMosque'](arg1);
+}
@@ -1,8 +1,45 @@
 export namespace models {
 	
+	export class APISettings {
+	    enabled: boolean;
+	    address: string;
+	    token: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new APISettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.address = source["address"];
+	        this.token = source["token"];
+	    }
+	}
+	export class AlarmAnchor {
+	    type: string;
+	    prayer?: string;
+	    sunEvent?: string;
+	    clockTime?: string;
+	    mosqueId?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AlarmAnchor(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.prayer = source["prayer"];
+	        this.sunEvent = source["sunEvent"];
+	        this.clockTime = source["clockTime"];
+	        this.mosqueId = source["mosqueId"];
+	    }
+	}
 	export class Alarm {
 	    id: number;
 	    prayer: string;
+	    anchor: AlarmAnchor;
 	    offsetMinutes: number;
 	    label: string;
 	    soundPath: string;
@@ -11,6 +48,7 @@ export namespace models {
 	    vibrationEnabled: boolean;
 	    createdAt: number;
 	    updatedAt: number;
+	    syncId?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new Alarm(source);
@@ -20,6 +58,7 @@ export namespace models {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.id = source["id"];
 	        this.prayer = source["prayer"];
+	        this.anchor = this.convertValues(source["anchor"], AlarmAnchor);
 	        this.offsetMinutes = source["offsetMinutes"];
 	        this.label = source["label"];
 	        this.soundPath = source["soundPath"];
@@ -28,6 +67,208 @@ export namespace models {
 	        this.vibrationEnabled = source["vibrationEnabled"];
 	        this.createdAt = source["createdAt"];
 	        this.updatedAt = source["updatedAt"];
+	        this.syncId = source["syncId"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class AlarmEvent {
+	    id: number;
+	    alarmId: number;
+	    prayer: string;
+	    label: string;
+	    type: string;
+	    scheduledAt: string;
+	    recordedAt: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AlarmEvent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.alarmId = source["alarmId"];
+	        this.prayer = source["prayer"];
+	        this.label = source["label"];
+	        this.type = source["type"];
+	        this.scheduledAt = source["scheduledAt"];
+	        this.recordedAt = source["recordedAt"];
+	    }
+	}
+	export class AlarmEventFilter {
+	    from: string;
+	    to: string;
+	    prayer: string;
+	    alarmId: number;
+	    types: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new AlarmEventFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.prayer = source["prayer"];
+	        this.alarmId = source["alarmId"];
+	        this.types = source["types"];
+	    }
+	}
+	export class AlarmOccurrence {
+	    alarmId: number;
+	    prayer: string;
+	    anchor: AlarmAnchor;
+	    label: string;
+	    time: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AlarmOccurrence(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.alarmId = source["alarmId"];
+	        this.prayer = source["prayer"];
+	        this.anchor = this.convertValues(source["anchor"], AlarmAnchor);
+	        this.label = source["label"];
+	        this.time = source["time"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ProfileTrigger {
+	    weekdays: number[];
+	    locationId: number;
+	    hijriMonths: number[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ProfileTrigger(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.weekdays = source["weekdays"];
+	        this.locationId = source["locationId"];
+	        this.hijriMonths = source["hijriMonths"];
+	    }
+	}
+	export class AlarmProfile {
+	    id: number;
+	    name: string;
+	    alarmIds: number[];
+	    isActive: boolean;
+	    autoActivate: ProfileTrigger;
+	    createdAt: number;
+	    updatedAt: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AlarmProfile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.alarmIds = source["alarmIds"];
+	        this.isActive = source["isActive"];
+	        this.autoActivate = this.convertValues(source["autoActivate"], ProfileTrigger);
+	        this.createdAt = source["createdAt"];
+	        this.updatedAt = source["updatedAt"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SyncSettings {
+	    directory: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SyncSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.directory = source["directory"];
+	    }
+	}
+	export class ForbiddenWindowSettings {
+	    sunriseMinutes: number;
+	    zenithMinutes: number;
+	    sunsetMinutes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ForbiddenWindowSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sunriseMinutes = source["sunriseMinutes"];
+	        this.zenithMinutes = source["zenithMinutes"];
+	        this.sunsetMinutes = source["sunsetMinutes"];
+	    }
+	}
+	export class JumuahSettings {
+	    enabled: boolean;
+	    khutbahTime: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new JumuahSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.khutbahTime = source["khutbahTime"];
 	    }
 	}
 	export class AppSettings {
@@ -39,6 +280,10 @@ export namespace models {
 	    enableVibration: boolean;
 	    theme: string;
 	    language: string;
+	    jumuah: JumuahSettings;
+	    forbiddenWindows: ForbiddenWindowSettings;
+	    sync: SyncSettings;
+	    api: APISettings;
 	
 	    static createFrom(source: any = {}) {
 	        return new AppSettings(source);
@@ -54,6 +299,110 @@ export namespace models {
 	        this.enableVibration = source["enableVibration"];
 	        this.theme = source["theme"];
 	        this.language = source["language"];
+	        this.jumuah = this.convertValues(source["jumuah"], JumuahSettings);
+	        this.forbiddenWindows = this.convertValues(source["forbiddenWindows"], ForbiddenWindowSettings);
+	        this.sync = this.convertValues(source["sync"], SyncSettings);
+	        this.api = this.convertValues(source["api"], APISettings);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ForbiddenWindow {
+	    kind: string;
+	    start: string;
+	    end: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ForbiddenWindow(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.start = source["start"];
+	        this.end = source["end"];
+	    }
+	}
+	
+	export class IqamahOverride {
+	    weekday: number;
+	    time: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new IqamahOverride(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.weekday = source["weekday"];
+	        this.time = source["time"];
+	    }
+	}
+	export class IqamahRule {
+	    mode: string;
+	    time: string;
+	    minutesAfter: number;
+	    overrides: IqamahOverride[];
+	
+	    static createFrom(source: any = {}) {
+	        return new IqamahRule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.mode = source["mode"];
+	        this.time = source["time"];
+	        this.minutesAfter = source["minutesAfter"];
+	        this.overrides = this.convertValues(source["overrides"], IqamahOverride);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class JumuahSlot {
+	    khutbahTime: string;
+	    iqamahTime: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new JumuahSlot(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.khutbahTime = source["khutbahTime"];
+	        this.iqamahTime = source["iqamahTime"];
 	    }
 	}
 	export class Location {
@@ -82,12 +431,278 @@ export namespace models {
 	        this.createdAt = source["createdAt"];
 	    }
 	}
+	export class PrayerDeviation {
+	    prayer: string;
+	    meanMinutes: number;
+	    maxMinutes: number;
+	    meanSignedMinutes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PrayerDeviation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.prayer = source["prayer"];
+	        this.meanMinutes = source["meanMinutes"];
+	        this.maxMinutes = source["maxMinutes"];
+	        this.meanSignedMinutes = source["meanSignedMinutes"];
+	    }
+	}
+	export class MethodComparison {
+	    rank: number;
+	    method: string;
+	    methodName: string;
+	    juristicMethod: string;
+	    meanMinutes: number;
+	    maxMinutes: number;
+	    prayers: PrayerDeviation[];
+	
+	    static createFrom(source: any = {}) {
+	        return new MethodComparison(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.rank = source["rank"];
+	        this.method = source["method"];
+	        this.methodName = source["methodName"];
+	        this.juristicMethod = source["juristicMethod"];
+	        this.meanMinutes = source["meanMinutes"];
+	        this.maxMinutes = source["maxMinutes"];
+	        this.prayers = this.convertValues(source["prayers"], PrayerDeviation);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class MethodComparisonReport {
+	    timetableId: number;
+	    timetableName: string;
+	    from: string;
+	    to: string;
+	    dates: number;
+	    results: MethodComparison[];
+	    recommended: MethodComparison;
+	    adjustments: Record<string, number>;
+	
+	    static createFrom(source: any = {}) {
+	        return new MethodComparisonReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.timetableId = source["timetableId"];
+	        this.timetableName = source["timetableName"];
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.dates = source["dates"];
+	        this.results = this.convertValues(source["results"], MethodComparison);
+	        this.recommended = this.convertValues(source["recommended"], MethodComparison);
+	        this.adjustments = source["adjustments"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Mosque {
+	    id: number;
+	    name: string;
+	    address: string;
+	    rules: Record<string, IqamahRule>;
+	    jumuah: JumuahSlot;
+	    isDefault: boolean;
+	    createdAt: number;
+	    updatedAt: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Mosque(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.address = source["address"];
+	        this.rules = this.convertValues(source["rules"], IqamahRule, true);
+	        this.jumuah = this.convertValues(source["jumuah"], JumuahSlot);
+	        this.isDefault = source["isDefault"];
+	        this.createdAt = source["createdAt"];
+	        this.updatedAt = source["updatedAt"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class NextPrayer {
+	    available: boolean;
+	    reason?: string;
+	    currentPrayer: string;
+	    currentStart: string;
+	    currentEnd: string;
+	    elapsedPercent: number;
+	    prayer: string;
+	    time: string;
+	    remainingSeconds: number;
+	    mosque?: string;
+	    iqamahTime?: string;
+	    iqamahRemainingSeconds?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new NextPrayer(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.available = source["available"];
+	        this.reason = source["reason"];
+	        this.currentPrayer = source["currentPrayer"];
+	        this.currentStart = source["currentStart"];
+	        this.currentEnd = source["currentEnd"];
+	        this.elapsedPercent = source["elapsedPercent"];
+	        this.prayer = source["prayer"];
+	        this.time = source["time"];
+	        this.remainingSeconds = source["remainingSeconds"];
+	        this.mosque = source["mosque"];
+	        this.iqamahTime = source["iqamahTime"];
+	        this.iqamahRemainingSeconds = source["iqamahRemainingSeconds"];
+	    }
+	}
+	
+	export class PrayerLogEntry {
+	    date: string;
+	    prayer: string;
+	    status: string;
+	    updatedAt: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PrayerLogEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.date = source["date"];
+	        this.prayer = source["prayer"];
+	        this.status = source["status"];
+	        this.updatedAt = source["updatedAt"];
+	    }
+	}
+	export class PrayerStat {
+	    prayer: string;
+	    logged: number;
+	    onTime: number;
+	    late: number;
+	    congregation: number;
+	    missed: number;
+	    onTimePercent: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PrayerStat(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.prayer = source["prayer"];
+	        this.logged = source["logged"];
+	        this.onTime = source["onTime"];
+	        this.late = source["late"];
+	        this.congregation = source["congregation"];
+	        this.missed = source["missed"];
+	        this.onTimePercent = source["onTimePercent"];
+	    }
+	}
+	export class PrayerStats {
+	    window: string;
+	    from: string;
+	    to: string;
+	    currentStreak: number;
+	    longestStreak: number;
+	    prayers: PrayerStat[];
+	
+	    static createFrom(source: any = {}) {
+	        return new PrayerStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.window = source["window"];
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.currentStreak = source["currentStreak"];
+	        this.longestStreak = source["longestStreak"];
+	        this.prayers = this.convertValues(source["prayers"], PrayerStat);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class PrayerTimes {
 	    fajr: string;
 	    dhuhr: string;
 	    asr: string;
 	    maghrib: string;
 	    isha: string;
+	    jumuah: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new PrayerTimes(source);
@@ -100,6 +715,372 @@ export namespace models {
 	        this.asr = source["asr"];
 	        this.maghrib = source["maghrib"];
 	        this.isha = source["isha"];
+	        this.jumuah = source["jumuah"];
+	    }
+	}
+	
+	export class QadaLedger {
+	    outstanding: Record<string, number>;
+	    completed: Record<string, number>;
+	    dailyGoal: number;
+	    syncedMissed: string[];
+	    updatedAt: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new QadaLedger(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.outstanding = source["outstanding"];
+	        this.completed = source["completed"];
+	        this.dailyGoal = source["dailyGoal"];
+	        this.syncedMissed = source["syncedMissed"];
+	        this.updatedAt = source["updatedAt"];
+	    }
+	}
+	export class QadaProjection {
+	    totalOutstanding: number;
+	    dailyGoal: number;
+	    daysRemaining: number;
+	    completionDate: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new QadaProjection(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.totalOutstanding = source["totalOutstanding"];
+	        this.dailyGoal = source["dailyGoal"];
+	        this.daysRemaining = source["daysRemaining"];
+	        this.completionDate = source["completionDate"];
+	    }
+	}
+	export class RestoreCounts {
+	    section: string;
+	    added: number;
+	    updated: number;
+	    removed: number;
+	    unchanged: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RestoreCounts(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.section = source["section"];
+	        this.added = source["added"];
+	        this.updated = source["updated"];
+	        this.removed = source["removed"];
+	        this.unchanged = source["unchanged"];
+	    }
+	}
+	export class RestoreSummary {
+	    mode: string;
+	    backupCreatedAt: string;
+	    sections: RestoreCounts[];
+	
+	    static createFrom(source: any = {}) {
+	        return new RestoreSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.mode = source["mode"];
+	        this.backupCreatedAt = source["backupCreatedAt"];
+	        this.sections = this.convertValues(source["sections"], RestoreCounts);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class StorageInfo {
+	    backend: string;
+	    dataDir: string;
+	    dataDirSource: string;
+	    sqliteAvailable: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new StorageInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.backend = source["backend"];
+	        this.dataDir = source["dataDir"];
+	        this.dataDirSource = source["dataDirSource"];
+	        this.sqliteAvailable = source["sqliteAvailable"];
+	    }
+	}
+	export class SunTimes {
+	    sunrise: string;
+	    solarNoon: string;
+	    sunset: string;
+	    midnight: string;
+	    lastThird: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SunTimes(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sunrise = source["sunrise"];
+	        this.solarNoon = source["solarNoon"];
+	        this.sunset = source["sunset"];
+	        this.midnight = source["midnight"];
+	        this.lastThird = source["lastThird"];
+	    }
+	}
+	export class SyncConflict {
+	    syncId: string;
+	    label: string;
+	    reason: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SyncConflict(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.syncId = source["syncId"];
+	        this.label = source["label"];
+	        this.reason = source["reason"];
+	    }
+	}
+	export class SyncResult {
+	    syncedAt: string;
+	    devices: number;
+	    alarmsAdded: number;
+	    alarmsUpdated: number;
+	    alarmsDeleted: number;
+	    locationsAdded: number;
+	    locationsDeleted: number;
+	    conflicts: SyncConflict[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SyncResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.syncedAt = source["syncedAt"];
+	        this.devices = source["devices"];
+	        this.alarmsAdded = source["alarmsAdded"];
+	        this.alarmsUpdated = source["alarmsUpdated"];
+	        this.alarmsDeleted = source["alarmsDeleted"];
+	        this.locationsAdded = source["locationsAdded"];
+	        this.locationsDeleted = source["locationsDeleted"];
+	        this.conflicts = this.convertValues(source["conflicts"], SyncConflict);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class TimetableEntry {
+	    date: string;
+	    fajr: string;
+	    dhuhr: string;
+	    asr: string;
+	    maghrib: string;
+	    isha: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TimetableEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.date = source["date"];
+	        this.fajr = source["fajr"];
+	        this.dhuhr = source["dhuhr"];
+	        this.asr = source["asr"];
+	        this.maghrib = source["maghrib"];
+	        this.isha = source["isha"];
+	    }
+	}
+	export class Timetable {
+	    id: number;
+	    name: string;
+	    locationName: string;
+	    latitude: number;
+	    longitude: number;
+	    entries: TimetableEntry[];
+	    importedAt: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Timetable(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.locationName = source["locationName"];
+	        this.latitude = source["latitude"];
+	        this.longitude = source["longitude"];
+	        this.entries = this.convertValues(source["entries"], TimetableEntry);
+	        this.importedAt = source["importedAt"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TimetableDiff {
+	    date: string;
+	    prayer: string;
+	    official: string;
+	    calculated: string;
+	    deltaMinutes: number;
+	    suspicious: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new TimetableDiff(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.date = source["date"];
+	        this.prayer = source["prayer"];
+	        this.official = source["official"];
+	        this.calculated = source["calculated"];
+	        this.deltaMinutes = source["deltaMinutes"];
+	        this.suspicious = source["suspicious"];
+	    }
+	}
+	
+	export class TimetableParseError {
+	    line: number;
+	    column: string;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TimetableParseError(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.line = source["line"];
+	        this.column = source["column"];
+	        this.message = source["message"];
+	    }
+	}
+	export class TimetableImportResult {
+	    timetableId: number;
+	    imported: number;
+	    from: string;
+	    to: string;
+	    errors: TimetableParseError[];
+	    diffs: TimetableDiff[];
+	
+	    static createFrom(source: any = {}) {
+	        return new TimetableImportResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.timetableId = source["timetableId"];
+	        this.imported = source["imported"];
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.errors = this.convertValues(source["errors"], TimetableParseError);
+	        this.diffs = this.convertValues(source["diffs"], TimetableDiff);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TimetableMapping {
+	    dateColumn: string;
+	    fajrColumn: string;
+	    dhuhrColumn: string;
+	    asrColumn: string;
+	    maghribColumn: string;
+	    ishaColumn: string;
+	    dateFormat: string;
+	    timeFormat: string;
+	    delimiter: string;
+	    hasHeader: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new TimetableMapping(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.dateColumn = source["dateColumn"];
+	        this.fajrColumn = source["fajrColumn"];
+	        this.dhuhrColumn = source["dhuhrColumn"];
+	        this.asrColumn = source["asrColumn"];
+	        this.maghribColumn = source["maghribColumn"];
+	        this.ishaColumn = source["ishaColumn"];
+	        this.dateFormat = source["dateFormat"];
+	        this.timeFormat = source["timeFormat"];
+	        this.delimiter = source["delimiter"];
+	        this.hasHeader = source["hasHeader"];
 	    }
 	}
 
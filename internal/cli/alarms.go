@@ -0,0 +1,248 @@
+// Package cli implements the command-line mode of the AzanAlarm application.
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"AzanAlarm/internal/models"
+)
+
+// runAlarms lists, adds or removes alarms.
+func runAlarms(e *env, args []string) error {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	switch args[0] {
+	case "list", "ls":
+		return runAlarmsList(e, args[1:])
+	case "add":
+		return runAlarmsAdd(e, args[1:])
+	case "rm", "remove":
+		return runAlarmsRemove(e, args[1:])
+	case "-h", "--help", "help":
+		fmt.Fprintln(e.stderr, "Usage: azanalarm alarms list|add|rm [options]")
+		return nil
+	default:
+		fmt.Fprintf(e.stderr, "unknown alarms command %q, expected list, add or rm\n", args[0])
+		return errUsage
+	}
+}
+
+// runAlarmsList prints all alarms.
+func runAlarmsList(e *env, args []string) error {
+	fs := e.newFlagSet("alarms list", "alarms list [-json]")
+	jsonFlag := fs.Bool("json", false, "print JSON")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	core, err := e.open()
+	if err != nil {
+		return err
+	}
+	alarms := core.Alarms.GetAlarms()
+	if *jsonFlag {
+		return e.writeJSON(alarms)
+	}
+	if len(alarms) == 0 {
+		fmt.Fprintln(e.stdout, "No alarms")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tACTIVE\tWHEN\tDAYS\tLABEL")
+	for _, alarm := range alarms {
+		active := "no"
+		if alarm.IsActive {
+			active = "yes"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", alarm.ID, active, describeWhen(alarm), describeDays(alarm.RepeatDays), alarm.Label)
+	}
+	return w.Flush()
+}
+
+// runAlarmsAdd creates an alarm.
+func runAlarmsAdd(e *env, args []string) error {
+	fs := e.newFlagSet("alarms add", "alarms add -at EVENT [-offset MIN] [-days DAYS] [-label TEXT] [-mosque ID] [-inactive] [-json]")
+	atFlag := fs.String("at", "", "prayer (fajr ... isha), jumuah, sun event (sunrise, solar_noon, sunset, midnight, last_third) or HH:MM")
+	offsetFlag := fs.Int("offset", 0, "minutes after the event, negative for before")
+	daysFlag := fs.String("days", "", "days to ring on, e.g. mon,wed,fri or 1-5 (default every day)")
+	labelFlag := fs.String("label", "", "label shown when the alarm rings")
+	mosqueFlag := fs.Int("mosque", 0, "ring relative to the iqamah of the prayer at this mosque")
+	inactiveFlag := fs.Bool("inactive", false, "create the alarm turned off")
+	jsonFlag := fs.Bool("json", false, "print the created alarm as JSON")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *atFlag == "" {
+		fmt.Fprintln(e.stderr, "-at is required")
+		fs.Usage()
+		return errUsage
+	}
+
+	anchor, err := parseAnchor(*atFlag, *mosqueFlag)
+	if err != nil {
+		return err
+	}
+	days, err := parseDays(*daysFlag)
+	if err != nil {
+		return err
+	}
+
+	alarm := models.NewAlarm(anchor.Prayer, *offsetFlag)
+	alarm.Anchor = anchor
+	alarm.RepeatDays = days
+	alarm.Label = *labelFlag
+	alarm.IsActive = !*inactiveFlag
+
+	core, err := e.open()
+	if err != nil {
+		return err
+	}
+	created, err := core.Alarms.CreateAlarm(alarm)
+	if err != nil {
+		return err
+	}
+	if *jsonFlag {
+		return e.writeJSON(created)
+	}
+	fmt.Fprintf(e.stdout, "Created alarm %d: %s, %s\n", created.ID, describeWhen(created), describeDays(created.RepeatDays))
+	return nil
+}
+
+// runAlarmsRemove deletes alarms by ID.
+func runAlarmsRemove(e *env, args []string) error {
+	fs := e.newFlagSet("alarms rm", "alarms rm ID...")
+	if err := parse(fs, args, -1); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(e.stderr, "no alarm ID given")
+		fs.Usage()
+		return errUsage
+	}
+	ids := make([]int, fs.NArg())
+	for i, arg := range fs.Args() {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			return models.ValidationError("invalid alarm ID %q", arg)
+		}
+		ids[i] = id
+	}
+
+	core, err := e.open()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := core.Alarms.DeleteAlarm(id); err != nil {
+			return err
+		}
+		if err := core.Profiles.RemoveAlarm(id); err != nil {
+			return err
+		}
+		fmt.Fprintf(e.stdout, "Removed alarm %d\n", id)
+	}
+	return nil
+}
+
+// parseAnchor parses the event an alarm is relative to. A mosque turns a
+// prayer into that prayer's iqamah.
+func parseAnchor(value string, mosqueID int) (models.AlarmAnchor, error) {
+	value = strings.ToLower(value)
+	if _, err := time.Parse("15:04", value); err == nil {
+		return models.AlarmAnchor{Type: models.AnchorClock, ClockTime: value}, nil
+	}
+	if value == string(models.Jumuah) {
+		return models.AlarmAnchor{Type: models.AnchorJumuah}, nil
+	}
+	for _, event := range models.AllSunEvents() {
+		if value == string(event) {
+			return models.AlarmAnchor{Type: models.AnchorSunEvent, SunEvent: event}, nil
+		}
+	}
+	for _, prayer := range models.AllPrayers() {
+		if value != string(prayer) {
+			continue
+		}
+		if mosqueID > 0 {
+			return models.AlarmAnchor{Type: models.AnchorIqamah, Prayer: prayer, MosqueID: mosqueID}, nil
+		}
+		return models.PrayerAnchor(prayer), nil
+	}
+	return models.AlarmAnchor{}, models.ValidationError("unknown event %q", value)
+}
+
+// parseDays parses a list of weekdays such as "mon,wed,fri", "1-5" or
+// "sat-sun". Days are numbered 1 (Monday) to 7 (Sunday).
+func parseDays(value string) ([]int, error) {
+	days := []int{}
+	if value == "" {
+		return days, nil
+	}
+	for _, part := range strings.Split(value, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, err := parseDay(from)
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if isRange {
+			if last, err = parseDay(to); err != nil {
+				return nil, err
+			}
+		}
+		for day := first; ; day = day%7 + 1 {
+			days = append(days, day)
+			if day == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseDay parses a weekday given as a number or the start of its name.
+func parseDay(value string) (int, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if day, err := strconv.Atoi(value); err == nil && day >= 1 && day <= 7 {
+		return day, nil
+	}
+	if len(value) >= 2 {
+		for day := 1; day <= 7; day++ {
+			if strings.HasPrefix(strings.ToLower(models.DayName(day)), value) {
+				return day, nil
+			}
+		}
+	}
+	return 0, models.ValidationError("unknown day %q", value)
+}
+
+// describeWhen describes when an alarm rings relative to its anchor.
+func describeWhen(alarm models.Alarm) string {
+	anchor := alarm.EffectiveAnchor().DisplayName()
+	switch {
+	case alarm.OffsetMinutes < 0:
+		return fmt.Sprintf("%d min before %s", -alarm.OffsetMinutes, anchor)
+	case alarm.OffsetMinutes > 0:
+		return fmt.Sprintf("%d min after %s", alarm.OffsetMinutes, anchor)
+	default:
+		return "At " + anchor
+	}
+}
+
+// describeDays describes the days an alarm rings on.
+func describeDays(days []int) string {
+	if len(days) == 0 || len(days) == 7 {
+		return "every day"
+	}
+	names := make([]string, len(days))
+	for i, day := range days {
+		names[i] = models.DayName(day)[:3]
+	}
+	return strings.Join(names, ",")
+}
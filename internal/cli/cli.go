@@ -0,0 +1,223 @@
+// Package cli implements the command-line mode of the AzanAlarm application.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"AzanAlarm/internal/models"
+	"AzanAlarm/internal/services"
+)
+
+// Exit codes returned by Run.
+const (
+	ExitOK    = 0
+	ExitError = 1 // The command failed
+	ExitUsage = 2 // The command line was invalid
+)
+
+// command is a subcommand of the command-line mode.
+type command struct {
+	name    string
+	summary string
+	run     func(env *env, args []string) error
+}
+
+// commands lists the subcommands in the order they are shown in the usage.
+var commands = []command{
+	{"times", "Show the prayer times of a day", runTimes},
+	{"next", "Show the current and the next prayer", runNext},
+	{"alarms", "List, add or remove alarms", runAlarms},
+	{"qibla", "Show the Qibla direction and the distance to Makkah", runQibla},
+	{"daemon", "Ring alarms in the background without a window", runDaemon},
+}
+
+// findCommand returns the command with the given name, or nil.
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+// env is what commands run with.
+type env struct {
+	dataDir string // Data directory override, empty for the default
+	core    *services.Core
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+// errUsage reports an invalid command line whose problem was already
+// printed.
+var errUsage = errors.New("invalid usage")
+
+// Run runs the command named by args[0] with the rest of args and returns
+// the exit code. dataDir overrides the data directory if not empty.
+func Run(dataDir string, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		Usage(stdout)
+		return ExitOK
+	}
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		fmt.Fprintf(stderr, "azanalarm: unknown command %q\n\n", args[0])
+		Usage(stderr)
+		return ExitUsage
+	}
+
+	e := &env{dataDir: dataDir, stdout: stdout, stderr: stderr}
+	err := cmd.run(e, args[1:])
+	if e.core != nil {
+		e.core.Storage.Close()
+	}
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, flag.ErrHelp):
+		return ExitOK
+	case errors.Is(err, errUsage):
+		return ExitUsage
+	default:
+		fmt.Fprintln(stderr, "azanalarm:", err)
+		return ExitError
+	}
+}
+
+// Usage prints the list of commands.
+func Usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: azanalarm [-data-dir DIR] <command> [options]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Without a command, azanalarm starts the desktop app and azanalarmd runs the daemon.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'azanalarm <command> -h' for the options of a command.")
+}
+
+// open opens the storage and services on first use.
+func (e *env) open() (*services.Core, error) {
+	if e.core == nil {
+		core, err := services.OpenCore(e.dataDir)
+		if err != nil {
+			return nil, err
+		}
+		e.core = core
+		for _, warning := range core.Storage.Warnings() {
+			fmt.Fprintln(e.stderr, "azanalarm: warning:", warning.Message)
+		}
+	}
+	return e.core, nil
+}
+
+// newFlagSet creates the flag set of a command, printing errors and help
+// to stderr.
+func (e *env) newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "Usage: azanalarm %s\n\nOptions:\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses the arguments of a command, allowing at most nargs
+// positional arguments, or any number if nargs is negative.
+func parse(fs *flag.FlagSet, args []string, nargs int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	if nargs >= 0 && fs.NArg() > nargs {
+		fmt.Fprintf(fs.Output(), "unexpected argument %q\n", fs.Arg(nargs))
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// writeJSON prints v as indented JSON.
+func (e *env) writeJSON(v interface{}) error {
+	encoder := json.NewEncoder(e.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// timeLayout returns the clock layout matching the 24-hour setting.
+func (e *env) timeLayout() string {
+	if e.core != nil && e.core.Settings.GetSettings().Is24HourFormat {
+		return "15:04"
+	}
+	return "3:04 PM"
+}
+
+// formatClock formats an ISO 8601 time string as a local clock time, or
+// returns "-" if it is empty or invalid.
+func (e *env) formatClock(value string) string {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return "-"
+	}
+	return t.In(time.Local).Format(e.timeLayout())
+}
+
+// formatDuration formats a number of seconds as hours and minutes.
+func formatDuration(seconds int) string {
+	d := time.Duration(seconds) * time.Second
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %02dm", hours, minutes)
+}
+
+// parseCoordinates checks a latitude and longitude given on the command
+// line. Both or neither must be set.
+func parseCoordinates(lat, lon string) (float64, float64, bool, error) {
+	if lat == "" && lon == "" {
+		return 0, 0, false, nil
+	}
+	if lat == "" || lon == "" {
+		return 0, 0, false, models.ValidationError("-lat and -lon must be given together")
+	}
+	var location models.Location
+	var err error
+	if location.Latitude, err = strconv.ParseFloat(lat, 64); err != nil {
+		return 0, 0, false, models.ValidationError("invalid latitude %q", lat)
+	}
+	if location.Longitude, err = strconv.ParseFloat(lon, 64); err != nil {
+		return 0, 0, false, models.ValidationError("invalid longitude %q", lon)
+	}
+	if err := location.Validate(); err != nil {
+		return 0, 0, false, err
+	}
+	return location.Latitude, location.Longitude, true, nil
+}
+
+// placeName describes the coordinates used by a command.
+func placeName(location *models.Location, latitude, longitude float64) string {
+	coordinates := fmt.Sprintf("%.4f, %.4f", latitude, longitude)
+	if location == nil || location.Name == "" {
+		return coordinates
+	}
+	name := location.Name
+	if location.Country != "" && !strings.Contains(name, location.Country) {
+		name += ", " + location.Country
+	}
+	return fmt.Sprintf("%s (%s)", name, coordinates)
+}
@@ -0,0 +1,107 @@
+// Package cli implements the command-line mode of the AzanAlarm application.
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"AzanAlarm/internal/daemon"
+	"AzanAlarm/internal/models"
+	"AzanAlarm/internal/services"
+)
+
+// runDaemon runs the alarm scheduler until it is stopped, or prints a
+// systemd user unit that runs it with the same options.
+func runDaemon(e *env, args []string) error {
+	fs := e.newFlagSet("daemon", "daemon [-exec CMD] [-webhook URL] [-events] [-prayers] [-log-format F] [-log-level L] [-print-unit]")
+	execFlag := fs.String("exec", "", "shell command run for every alarm, with the event in AZANALARM_* variables")
+	execTimeoutFlag := fs.Duration("exec-timeout", 10*time.Minute, "how long the command may run")
+	webhookFlag := fs.String("webhook", "", "URL every event is POSTed to as JSON")
+	eventsFlag := fs.Bool("events", false, "write events to stdout as JSON lines")
+	prayersFlag := fs.Bool("prayers", false, "also publish an event when each prayer time begins")
+	logFormatFlag := fs.String("log-format", "text", "log format, text or json")
+	logLevelFlag := fs.String("log-level", "info", "log level, debug, info, warn or error")
+	printUnitFlag := fs.Bool("print-unit", false, "print a systemd user unit running the daemon with these options and exit")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(*logLevelFlag)); err != nil {
+		return models.ValidationError("unknown log level %q", *logLevelFlag)
+	}
+	handlerOptions := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch *logFormatFlag {
+	case "text":
+		handler = slog.NewTextHandler(e.stderr, handlerOptions)
+	case "json":
+		handler = slog.NewJSONHandler(e.stderr, handlerOptions)
+	default:
+		return models.ValidationError("unknown log format %q, expected text or json", *logFormatFlag)
+	}
+
+	if *printUnitFlag {
+		command, err := e.daemonCommand(fs)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(e.stdout, daemon.SystemdUnit(command))
+		return nil
+	}
+
+	options := daemon.Options{
+		Exec:        *execFlag,
+		ExecTimeout: *execTimeoutFlag,
+		Webhook:     *webhookFlag,
+		Prayers:     *prayersFlag,
+	}
+	if *eventsFlag {
+		options.Events = e.stdout
+	}
+
+	core, err := e.open()
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return daemon.New(core, options, slog.New(handler)).Run(ctx)
+}
+
+// daemonCommand returns the command line that runs the daemon with the
+// options set on fs, other than -print-unit. The data directory is made
+// explicit, since services do not see the environment of the shell.
+func (e *env) daemonCommand(fs *flag.FlagSet) ([]string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	command := []string{exe}
+
+	dataDir, source, err := services.ResolveDataDir(e.dataDir)
+	if err != nil {
+		return nil, err
+	}
+	if source == services.DataDirFromFlag || source == services.DataDirFromEnv {
+		command = append(command, "-data-dir", dataDir)
+	}
+
+	command = append(command, "daemon")
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name != "print-unit" {
+			command = append(command, fmt.Sprintf("-%s=%s", f.Name, f.Value))
+		}
+	})
+	return command, nil
+}
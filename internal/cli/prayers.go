@@ -0,0 +1,201 @@
+// Package cli implements the command-line mode of the AzanAlarm application.
+package cli
+
+import (
+	"fmt"
+	"math"
+	"text/tabwriter"
+	"time"
+
+	"AzanAlarm/internal/models"
+)
+
+// timesOutput is the JSON output of the times command.
+type timesOutput struct {
+	Date              string                         `json:"date"` // YYYY-MM-DD
+	Latitude          float64                        `json:"latitude"`
+	Longitude         float64                        `json:"longitude"`
+	CalculationMethod models.PrayerCalculationMethod `json:"calculationMethod"`
+	JuristicMethod    models.JuristicMethod          `json:"juristicMethod"`
+	Times             models.PrayerTimes             `json:"times"`
+}
+
+// runTimes prints the prayer times of a day. Without options it matches
+// the app, using the current location, the configured methods and any
+// imported timetable; options calculate the times instead.
+func runTimes(e *env, args []string) error {
+	fs := e.newFlagSet("times", "times [-date YYYY-MM-DD] [-method M] [-juristic J] [-lat LAT -lon LON] [-json]")
+	dateFlag := fs.String("date", "", "date as YYYY-MM-DD (default today)")
+	methodFlag := fs.String("method", "", "calculation method, e.g. isna or muslim_world_league (default from settings)")
+	juristicFlag := fs.String("juristic", "", "juristic method for Asr, shafii or hanafi (default from settings)")
+	latFlag := fs.String("lat", "", "latitude (default the current location)")
+	lonFlag := fs.String("lon", "", "longitude (default the current location)")
+	jsonFlag := fs.Bool("json", false, "print JSON")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	date := time.Now()
+	if *dateFlag != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", *dateFlag, time.Local)
+		if err != nil {
+			return models.ValidationError("invalid date %q, expected YYYY-MM-DD", *dateFlag)
+		}
+		date = parsed
+	}
+	latitude, longitude, hasCoordinates, err := parseCoordinates(*latFlag, *lonFlag)
+	if err != nil {
+		return err
+	}
+
+	core, err := e.open()
+	if err != nil {
+		return err
+	}
+	settings := core.Settings.GetSettings()
+	if *methodFlag != "" {
+		settings.CalculationMethod = models.PrayerCalculationMethod(*methodFlag)
+	}
+	if *juristicFlag != "" {
+		settings.JuristicMethod = models.JuristicMethod(*juristicFlag)
+	}
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	var location *models.Location
+	if !hasCoordinates {
+		if location = core.Locations.GetCurrentLocation(); location == nil {
+			return models.NotConfiguredError("no location set, pass -lat and -lon")
+		}
+		latitude, longitude = location.Latitude, location.Longitude
+	}
+
+	var times models.PrayerTimes
+	if hasCoordinates || *methodFlag != "" || *juristicFlag != "" {
+		times = core.Schedule.CalculatePrayerTimes(latitude, longitude, date, settings.CalculationMethod, settings.JuristicMethod)
+	} else {
+		times, _ = core.Schedule.PrayerTimesFor(date)
+	}
+
+	if *jsonFlag {
+		return e.writeJSON(timesOutput{
+			Date:              date.Format("2006-01-02"),
+			Latitude:          latitude,
+			Longitude:         longitude,
+			CalculationMethod: settings.CalculationMethod,
+			JuristicMethod:    settings.JuristicMethod,
+			Times:             times,
+		})
+	}
+
+	fmt.Fprintf(e.stdout, "Prayer times for %s\n", date.Format("Monday, 2 January 2006"))
+	fmt.Fprintf(e.stdout, "%s, %s\n\n", placeName(location, latitude, longitude), settings.CalculationMethod.DisplayName())
+	w := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+	for _, prayer := range models.AllPrayers() {
+		fmt.Fprintf(w, "%s\t%s\n", prayer.DisplayName(), e.formatClock(times.GetTime(prayer)))
+	}
+	if times.Jumuah != "" {
+		fmt.Fprintf(w, "%s\t%s\n", models.Jumuah.DisplayName(), e.formatClock(times.Jumuah))
+	}
+	return w.Flush()
+}
+
+// runNext prints the current prayer window and the next prayer.
+func runNext(e *env, args []string) error {
+	fs := e.newFlagSet("next", "next [-json]")
+	jsonFlag := fs.Bool("json", false, "print JSON")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	core, err := e.open()
+	if err != nil {
+		return err
+	}
+	next := core.Schedule.NextPrayerAt(time.Now())
+	if *jsonFlag {
+		if err := e.writeJSON(next); err != nil {
+			return err
+		}
+	}
+	if !next.Available {
+		if next.Reason == models.NextPrayerNoLocation {
+			return models.NotConfiguredError("no location set")
+		}
+		return fmt.Errorf("prayer times cannot be calculated for the current location")
+	}
+	if *jsonFlag {
+		return nil
+	}
+
+	fmt.Fprintf(e.stdout, "%s at %s, in %s\n",
+		next.Prayer.DisplayName(), e.formatClock(next.Time), formatDuration(next.RemainingSeconds))
+	if next.IqamahTime != "" {
+		fmt.Fprintf(e.stdout, "Iqamah at %s: %s, in %s\n",
+			next.Mosque, e.formatClock(next.IqamahTime), formatDuration(next.IqamahRemainingSeconds))
+	}
+	fmt.Fprintf(e.stdout, "Now: %s since %s (%.0f%% of its time has passed)\n",
+		next.CurrentPrayer.DisplayName(), e.formatClock(next.CurrentStart), next.ElapsedPercent)
+	return nil
+}
+
+// qiblaOutput is the JSON output of the qibla command.
+type qiblaOutput struct {
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	Direction  float64 `json:"direction"`  // Degrees clockwise from true north
+	DistanceKm float64 `json:"distanceKm"` // Great-circle distance to the Kaaba
+}
+
+// runQibla prints the Qibla direction and the distance to Makkah.
+func runQibla(e *env, args []string) error {
+	fs := e.newFlagSet("qibla", "qibla [-lat LAT -lon LON] [-json]")
+	latFlag := fs.String("lat", "", "latitude (default the current location)")
+	lonFlag := fs.String("lon", "", "longitude (default the current location)")
+	jsonFlag := fs.Bool("json", false, "print JSON")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	latitude, longitude, hasCoordinates, err := parseCoordinates(*latFlag, *lonFlag)
+	if err != nil {
+		return err
+	}
+	core, err := e.open()
+	if err != nil {
+		return err
+	}
+	var location *models.Location
+	if !hasCoordinates {
+		if location = core.Locations.GetCurrentLocation(); location == nil {
+			return models.NotConfiguredError("no location set, pass -lat and -lon")
+		}
+		latitude, longitude = location.Latitude, location.Longitude
+	}
+
+	output := qiblaOutput{
+		Latitude:   latitude,
+		Longitude:  longitude,
+		Direction:  core.Qibla.GetQiblaDirection(latitude, longitude),
+		DistanceKm: core.Qibla.GetDistanceToMakkah(latitude, longitude),
+	}
+	if *jsonFlag {
+		return e.writeJSON(output)
+	}
+
+	fmt.Fprintf(e.stdout, "Qibla from %s\n", placeName(location, latitude, longitude))
+	fmt.Fprintf(e.stdout, "Direction: %.1f° (%s) from true north\n", output.Direction, compassPoint(output.Direction))
+	fmt.Fprintf(e.stdout, "Distance to Makkah: %.0f km\n", output.DistanceKm)
+	return nil
+}
+
+// compassPoint returns the nearest of the 16 compass points to a bearing.
+func compassPoint(degrees float64) string {
+	points := []string{
+		"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE",
+		"S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW",
+	}
+	i := int(math.Round(math.Mod(degrees+360, 360)/22.5)) % len(points)
+	return points[i]
+}
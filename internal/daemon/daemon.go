@@ -0,0 +1,349 @@
+// Package daemon runs the alarm scheduler of the AzanAlarm application
+// without a user interface, for always-on machines such as home servers.
+package daemon
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"AzanAlarm/internal/models"
+	"AzanAlarm/internal/services"
+)
+
+// tickInterval is how often the daemon checks for alarms that are due.
+const tickInterval = time.Second
+
+// maintenanceInterval is how often profiles, sync and missed alarms are
+// checked, like the desktop app does.
+const maintenanceInterval = 30 * time.Second
+
+// missedGracePeriod is how late an alarm may still be fired, e.g. after
+// the machine was suspended. Later alarms are recorded as missed instead.
+const missedGracePeriod = 2 * time.Minute
+
+// Event types.
+const (
+	EventAlarm  = "alarm"  // An alarm rang
+	EventPrayer = "prayer" // A prayer time began, if prayer events are enabled
+)
+
+// Event is published when an alarm rings or a prayer time begins.
+type Event struct {
+	Type    string        `json:"type"`              // EventAlarm or EventPrayer
+	Time    string        `json:"time"`              // ISO 8601 time the event was due
+	AlarmID int           `json:"alarmId,omitempty"` // Set for alarms
+	Prayer  models.Prayer `json:"prayer,omitempty"`
+	Label   string        `json:"label"`
+}
+
+// Options configures how the daemon announces events.
+type Options struct {
+	Exec        string        // Shell command run for every event, with the event in its environment
+	ExecTimeout time.Duration // How long the command may run, e.g. while playing the adhan
+	Webhook     string        // URL every event is POSTed to as JSON
+	Events      io.Writer     // If set, events are written to it as JSON lines
+	Prayers     bool          // Also publish an event when each prayer time begins
+}
+
+// Daemon fires alarms at their time, keeps the alarm history, applies
+// alarm profiles, syncs and serves the local API if it is enabled.
+type Daemon struct {
+	core    *services.Core
+	options Options
+	log     *slog.Logger
+	api     *services.APIServer
+	client  *http.Client
+
+	eventsMu sync.Mutex     // Serialises writes to options.Events
+	running  sync.WaitGroup // Commands and webhooks in flight
+
+	syncConflicts map[string]bool // Sync IDs of the conflicts already logged
+}
+
+// New creates a new Daemon on top of the services.
+func New(core *services.Core, options Options, log *slog.Logger) *Daemon {
+	d := &Daemon{
+		core:    core,
+		options: options,
+		log:     log,
+		api: services.NewAPIServer(
+			core.Settings,
+			core.Schedule,
+			core.Alarms,
+			core.Profiles,
+			core.Locations,
+		),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	d.api.OnError(func(err error) {
+		d.log.Error("API error", "error", err)
+	})
+	return d
+}
+
+// Run fires alarms until the context is cancelled. Reload signals, SIGHUP
+// on Unix, reload all data from storage.
+func (d *Daemon) Run(ctx context.Context) error {
+	d.log.Info("daemon started",
+		"dataDir", d.core.Storage.GetDataDir(),
+		"backend", d.core.Storage.BackendName(),
+		"alarms", len(d.core.Alarms.GetActiveAlarms()))
+	if d.core.Locations.GetCurrentLocation() == nil {
+		d.log.Warn("no location set, only clock alarms will ring")
+	}
+	d.reportStorageWarnings()
+	d.applyAPI()
+	defer d.api.Stop()
+
+	reload := make(chan os.Signal, 1)
+	if len(reloadSignals) > 0 {
+		signal.Notify(reload, reloadSignals...)
+		defer signal.Stop(reload)
+	}
+	go d.watchStorage(ctx)
+
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	// Strip the monotonic reading, it does not advance during sleep
+	last := time.Now().Round(0)
+	lastMaintenance := time.Time{}
+	for {
+		select {
+		case <-ctx.Done():
+			d.log.Info("daemon stopping")
+			d.running.Wait()
+			return nil
+		case sig := <-reload:
+			d.log.Info("reloading", "signal", sig.String())
+			d.reloadAll()
+		case <-ticker.C:
+			now := time.Now().Round(0)
+			from := last
+			if earliest := now.Add(-missedGracePeriod); from.Before(earliest) {
+				d.log.Warn("clock jumped or system was suspended", "from", from.Format(time.RFC3339), "to", now.Format(time.RFC3339))
+				from = earliest
+			}
+			d.fireDue(ctx, from, now)
+			last = now
+
+			if now.Sub(lastMaintenance) >= maintenanceInterval {
+				d.maintain(now)
+				lastMaintenance = now
+			}
+		}
+	}
+}
+
+// fireDue publishes the alarms, and prayer times if enabled, in [from, to).
+func (d *Daemon) fireDue(ctx context.Context, from, to time.Time) {
+	if !from.Before(to) {
+		return
+	}
+	for _, o := range d.core.Schedule.AlarmsBetween(from, to) {
+		event := models.AlarmEvent{
+			AlarmID:     o.AlarmID,
+			Prayer:      o.Prayer,
+			Label:       o.Label,
+			Type:        models.AlarmFired,
+			ScheduledAt: o.Time,
+		}
+		if _, err := d.core.AlarmEvents.RecordEvent(event); err != nil {
+			d.log.Error("cannot record alarm event", "alarmId", o.AlarmID, "error", err)
+		}
+		d.publish(ctx, Event{
+			Type:    EventAlarm,
+			Time:    o.Time,
+			AlarmID: o.AlarmID,
+			Prayer:  o.Prayer,
+			Label:   o.Label,
+		})
+	}
+
+	if !d.options.Prayers {
+		return
+	}
+	for date := startOfDay(from); date.Before(to); date = date.AddDate(0, 0, 1) {
+		times, ok := d.core.Schedule.PrayerTimesFor(date)
+		if !ok {
+			return
+		}
+		for _, prayer := range models.AllPrayers() {
+			at, err := time.Parse(time.RFC3339, times.GetTime(prayer))
+			if err != nil || at.Before(from) || !at.Before(to) {
+				continue
+			}
+			d.publish(ctx, Event{
+				Type:   EventPrayer,
+				Time:   times.GetTime(prayer),
+				Prayer: prayer,
+				Label:  prayer.DisplayName(),
+			})
+		}
+	}
+}
+
+// maintain reports storage problems, applies automatic alarm profiles,
+// syncs with other devices and records missed alarms.
+func (d *Daemon) maintain(now time.Time) {
+	d.reportStorageWarnings()
+
+	if profile, err := d.core.Profiles.ApplyAutomatic(now); err != nil {
+		d.log.Error("cannot activate alarm profile", "error", err)
+	} else if profile != nil {
+		d.log.Info("alarm profile activated", "profile", profile.Name)
+	}
+
+	if d.core.Sync.IsConfigured() {
+		result, err := d.core.Sync.Sync(now)
+		if err != nil {
+			d.log.Error("cannot sync", "error", err)
+		} else {
+			if result.Changed() {
+				d.log.Info("synced",
+					"devices", result.Devices,
+					"alarmsAdded", result.AlarmsAdded,
+					"alarmsUpdated", result.AlarmsUpdated,
+					"alarmsDeleted", result.AlarmsDeleted,
+					"locationsAdded", result.LocationsAdded,
+					"locationsDeleted", result.LocationsDeleted)
+			}
+			d.reportSyncConflicts(result.Conflicts)
+		}
+	}
+
+	until := now.Add(-missedGracePeriod)
+	from, ok := d.core.AlarmEvents.MissedCheckWindow(until)
+	if !ok {
+		if d.core.AlarmEvents.LastCheckedAt().IsZero() {
+			if err := d.core.AlarmEvents.MarkChecked(until); err != nil {
+				d.log.Error("cannot save alarm check state", "error", err)
+			}
+		}
+		return
+	}
+	missed, err := d.core.AlarmEvents.RecordMissed(d.core.Schedule.AlarmsBetween(from, until), until)
+	if err != nil {
+		d.log.Error("cannot record missed alarms", "error", err)
+		return
+	}
+	for _, event := range missed {
+		d.log.Warn("alarm missed", "alarmId", event.AlarmID, "label", event.Label, "scheduledAt", event.ScheduledAt)
+	}
+}
+
+// reportStorageWarnings logs data that was recovered from a backup or
+// could not be loaded at all.
+func (d *Daemon) reportStorageWarnings() {
+	for _, warning := range d.core.Storage.Warnings() {
+		if warning.Unreadable {
+			d.log.Error("data not loaded", "key", warning.Key, "reason", warning.Message)
+		} else {
+			d.log.Warn("data recovered from backup", "key", warning.Key, "reason", warning.Message)
+		}
+	}
+}
+
+// reportSyncConflicts logs the alarms from other devices that cannot be
+// used here. Each is logged once for as long as it stays in conflict.
+func (d *Daemon) reportSyncConflicts(conflicts []models.SyncConflict) {
+	current := make(map[string]bool, len(conflicts))
+	for _, c := range conflicts {
+		current[c.SyncID] = true
+		if !d.syncConflicts[c.SyncID] {
+			d.log.Warn("alarm not synced", "alarm", c.Label, "reason", c.Reason)
+		}
+	}
+	d.syncConflicts = current
+}
+
+// reloaders returns the functions that reload each storage key, in the
+// order they should run.
+func (d *Daemon) reloaders() []struct {
+	key    string
+	reload func() error
+} {
+	return []struct {
+		key    string
+		reload func() error
+	}{
+		{"settings", d.core.Settings.Reload},
+		{"alarms", d.core.Alarms.Reload},
+		{"alarm_profiles", d.core.Profiles.Reload},
+		{"mosques", d.core.Mosques.Reload},
+		{"prayer_log", d.core.PrayerLog.Reload},
+		{"qada", d.core.Qada.Reload},
+		{"profile_auto", d.core.Profiles.ReloadAutoState},
+		{"alarm_events", d.core.AlarmEvents.Reload},
+		{"alarm_check", d.core.AlarmEvents.ReloadCheckState},
+		{"timetables", d.core.Timetables.Reload},
+		{"sync_state", d.core.Sync.Reload},
+	}
+}
+
+// reloadAll reloads all data from storage, keeping the current data of
+// keys that fail to load.
+func (d *Daemon) reloadAll() {
+	for _, r := range d.reloaders() {
+		err := r.reload()
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // Never saved
+		}
+		if err != nil {
+			d.log.Error("cannot reload", "key", r.key, "error", err)
+		}
+	}
+	d.applyAPI()
+	d.log.Info("reloaded", "alarms", len(d.core.Alarms.GetActiveAlarms()))
+}
+
+// watchStorage reloads data files changed by other programs, such as the
+// command-line mode or a sync tool.
+func (d *Daemon) watchStorage(ctx context.Context) {
+	watcher, err := services.NewStorageWatcher(d.core.Storage)
+	if err != nil {
+		d.log.Info("not watching data directory, send a reload signal after changes", "reason", err)
+		return
+	}
+	for _, r := range d.reloaders() {
+		watcher.OnChange(r.key, r.reload)
+	}
+	// Locations are read from storage on every call
+	watcher.OnChange("current_location", nil)
+	watcher.OnChange("saved_locations", nil)
+
+	watcher.Run(ctx, func(change models.StorageChange) {
+		if change.Error != "" {
+			d.log.Error("ignored external change", "key", change.Key, "error", change.Error)
+			return
+		}
+		d.log.Info("reloaded external change", "key", change.Key)
+		if change.Key == "settings" {
+			d.applyAPI()
+		}
+	})
+}
+
+// applyAPI starts or stops the local API to match the settings.
+func (d *Daemon) applyAPI() {
+	if err := d.api.Apply(); err != nil {
+		d.log.Error("cannot start API", "error", err)
+		return
+	}
+	if address := d.api.Address(); address != "" {
+		d.log.Info("API listening", "address", address)
+	}
+}
+
+// startOfDay returns midnight local time on the given date.
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+// Package daemon runs the alarm scheduler of the AzanAlarm application
+// without a user interface, for always-on machines such as home servers.
+package daemon
+
+import (
+	"os"
+	"syscall"
+)
+
+// reloadSignals are the signals that reload all data from storage.
+var reloadSignals = []os.Signal{syscall.SIGHUP}
+
+// shell returns the shell that runs commands and its flag for a command
+// line.
+func shell() (string, string) {
+	return "/bin/sh", "-c"
+}
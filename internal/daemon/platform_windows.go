@@ -0,0 +1,17 @@
+//go:build windows
+
+// Package daemon runs the alarm scheduler of the AzanAlarm application
+// without a user interface, for always-on machines such as home servers.
+package daemon
+
+import "os"
+
+// reloadSignals are the signals that reload all data from storage. Windows
+// has none; changed files are still picked up by the storage watcher.
+var reloadSignals []os.Signal
+
+// shell returns the shell that runs commands and its flag for a command
+// line.
+func shell() (string, string) {
+	return "cmd", "/C"
+}
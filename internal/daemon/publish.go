@@ -0,0 +1,109 @@
+// Package daemon runs the alarm scheduler of the AzanAlarm application
+// without a user interface, for always-on machines such as home servers.
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// publish announces an event through the log, the event stream, the
+// command and the webhook. Commands and webhooks run in the background.
+func (d *Daemon) publish(ctx context.Context, event Event) {
+	d.log.Info(event.Type,
+		"time", event.Time,
+		"alarmId", event.AlarmID,
+		"prayer", string(event.Prayer),
+		"label", event.Label)
+
+	if d.options.Events != nil {
+		d.eventsMu.Lock()
+		if err := json.NewEncoder(d.options.Events).Encode(event); err != nil {
+			d.log.Error("cannot write event", "error", err)
+		}
+		d.eventsMu.Unlock()
+	}
+	if d.options.Exec != "" {
+		d.running.Add(1)
+		go func() {
+			defer d.running.Done()
+			d.runCommand(ctx, event)
+		}()
+	}
+	if d.options.Webhook != "" {
+		d.running.Add(1)
+		go func() {
+			defer d.running.Done()
+			d.postWebhook(ctx, event)
+		}()
+	}
+}
+
+// runCommand runs the configured command for an event. The event is passed
+// in AZANALARM_* environment variables.
+func (d *Daemon) runCommand(ctx context.Context, event Event) {
+	if d.options.ExecTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.options.ExecTimeout)
+		defer cancel()
+	}
+
+	cmd := shellCommand(ctx, d.options.Exec)
+	cmd.Env = append(os.Environ(),
+		"AZANALARM_EVENT="+event.Type,
+		"AZANALARM_TIME="+event.Time,
+		"AZANALARM_ALARM_ID="+strconv.Itoa(event.AlarmID),
+		"AZANALARM_PRAYER="+string(event.Prayer),
+		"AZANALARM_LABEL="+event.Label,
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		d.log.Error("command failed",
+			"command", d.options.Exec,
+			"event", event.Type,
+			"error", err,
+			"output", strings.TrimSpace(string(output)))
+		return
+	}
+	d.log.Debug("command finished", "command", d.options.Exec, "output", strings.TrimSpace(string(output)))
+}
+
+// postWebhook POSTs an event to the configured webhook as JSON.
+func (d *Daemon) postWebhook(ctx context.Context, event Event) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		d.log.Error("cannot encode event", "error", err)
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.options.Webhook, bytes.NewReader(body))
+	if err != nil {
+		d.log.Error("invalid webhook", "url", d.options.Webhook, "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.client.Do(req)
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			err = fmt.Errorf("server replied %s", resp.Status)
+		}
+	}
+	if err != nil {
+		d.log.Error("webhook failed", "url", d.options.Webhook, "event", event.Type, "error", err)
+	}
+}
+
+// shellCommand creates a command that runs a command line through the
+// system shell.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	name, flag := shell()
+	return exec.CommandContext(ctx, name, flag, command)
+}
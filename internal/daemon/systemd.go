@@ -0,0 +1,49 @@
+// Package daemon runs the alarm scheduler of the AzanAlarm application
+// without a user interface, for always-on machines such as home servers.
+package daemon
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SystemdUnit returns a systemd user unit that runs the daemon with the
+// given command line, reloading it with SIGHUP on "systemctl --user
+// reload".
+func SystemdUnit(command []string) string {
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = quoteSystemd(arg)
+	}
+
+	var b strings.Builder
+	fmt.Fprintln(&b, "[Unit]")
+	fmt.Fprintln(&b, "Description=AzanAlarm prayer time alarms")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "[Service]")
+	fmt.Fprintln(&b, "Type=exec")
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(quoted, " "))
+	fmt.Fprintln(&b, "ExecReload=/bin/kill -HUP $MAINPID")
+	fmt.Fprintln(&b, "Restart=on-failure")
+	fmt.Fprintln(&b, "RestartSec=10")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "[Install]")
+	fmt.Fprintln(&b, "WantedBy=default.target")
+	return b.String()
+}
+
+// quoteSystemd quotes an argument for an Exec line of a systemd unit, where
+// "%" starts a specifier and "$" a variable.
+func quoteSystemd(arg string) string {
+	escaped := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"%", "%%",
+		"$", "$$",
+		"\n", `\n`,
+	).Replace(arg)
+	if escaped != "" && !strings.ContainsAny(escaped, " \t'\"\\;") {
+		return escaped
+	}
+	return `"` + escaped + `"`
+}
@@ -6,18 +6,33 @@ import (
 	"time"
 )
 
+// MaxAlarmOffsetMinutes is the largest offset, before or after its anchor,
+// that an alarm may have.
+const MaxAlarmOffsetMinutes = 12 * 60
+
 // Alarm represents a prayer time alarm.
 type Alarm struct {
-	ID               int    `json:"id"`
-	Prayer           Prayer `json:"prayer"`
-	OffsetMinutes    int    `json:"offsetMinutes"` // Negative for before, positive for after
-	Label            string `json:"label"`
-	SoundPath        string `json:"soundPath"`
-	IsActive         bool   `json:"isActive"`
-	RepeatDays       []int  `json:"repeatDays"` // 1=Monday, 7=Sunday
-	VibrationEnabled bool   `json:"vibrationEnabled"`
-	CreatedAt        int64  `json:"createdAt"` // Unix timestamp in milliseconds
-	UpdatedAt        int64  `json:"updatedAt"` // Unix timestamp in milliseconds
+	ID               int         `json:"id"`
+	Prayer           Prayer      `json:"prayer"`        // Set for prayer, iqamah and Jumu'ah anchors, kept for older alarms
+	Anchor           AlarmAnchor `json:"anchor"`        // Empty type means anchored to Prayer
+	OffsetMinutes    int         `json:"offsetMinutes"` // Negative for before, positive for after
+	Label            string      `json:"label"`
+	SoundPath        string      `json:"soundPath"`
+	IsActive         bool        `json:"isActive"`
+	RepeatDays       []int       `json:"repeatDays"` // 1=Monday, 7=Sunday
+	VibrationEnabled bool        `json:"vibrationEnabled"`
+	CreatedAt        int64       `json:"createdAt"`        // Unix timestamp in milliseconds
+	UpdatedAt        int64       `json:"updatedAt"`        // Unix timestamp in milliseconds, not changed by switching the alarm on or off
+	SyncID           string      `json:"syncId,omitempty"` // Identifies the alarm across synced devices
+}
+
+// AlarmOccurrence represents a concrete instant at which an alarm will ring.
+type AlarmOccurrence struct {
+	AlarmID int         `json:"alarmId"`
+	Prayer  Prayer      `json:"prayer"`
+	Anchor  AlarmAnchor `json:"anchor"`
+	Label   string      `json:"label"`
+	Time    string      `json:"time"` // ISO 8601 time string
 }
 
 // NewAlarm creates a new alarm with default values.
@@ -25,6 +40,7 @@ func NewAlarm(prayer Prayer, offsetMinutes int) Alarm {
 	now := time.Now().UnixMilli()
 	return Alarm{
 		Prayer:           prayer,
+		Anchor:           PrayerAnchor(prayer),
 		OffsetMinutes:    offsetMinutes,
 		IsActive:         true,
 		RepeatDays:       []int{}, // Empty means every day
@@ -34,6 +50,99 @@ func NewAlarm(prayer Prayer, offsetMinutes int) Alarm {
 	}
 }
 
+// Clone returns a copy of the alarm that shares no memory with it.
+func (a Alarm) Clone() Alarm {
+	if a.RepeatDays != nil {
+		a.RepeatDays = append([]int{}, a.RepeatDays...)
+	}
+	return a
+}
+
+// EffectiveAnchor returns the anchor of the alarm. Alarms saved before
+// anchors existed only have Prayer set and are anchored to it.
+func (a *Alarm) EffectiveAnchor() AlarmAnchor {
+	if a.Anchor.Type == "" {
+		return PrayerAnchor(a.Prayer)
+	}
+	return a.Anchor
+}
+
+// NormalizeAnchor fills in the anchor of older alarms and keeps Prayer in
+// sync with it.
+func (a *Alarm) NormalizeAnchor() {
+	a.Anchor = a.EffectiveAnchor()
+	switch a.Anchor.Type {
+	case AnchorPrayer, AnchorIqamah:
+		a.Prayer = a.Anchor.Prayer
+	case AnchorJumuah:
+		a.Prayer = Jumuah
+	default:
+		a.Prayer = ""
+	}
+}
+
+// Validate checks the anchor, offset and repeat days of the alarm.
+func (a *Alarm) Validate() error {
+	var errs ValidationErrors
+
+	anchor := a.EffectiveAnchor()
+	switch anchor.Type {
+	case AnchorPrayer:
+		if !isDailyPrayer(anchor.Prayer) {
+			errs.Add("anchor.prayer", "unknown prayer %q", anchor.Prayer)
+		}
+	case AnchorIqamah:
+		if !isDailyPrayer(anchor.Prayer) {
+			errs.Add("anchor.prayer", "unknown prayer %q", anchor.Prayer)
+		}
+		if anchor.MosqueID <= 0 {
+			errs.Add("anchor.mosqueId", "a mosque is required for iqamah alarms")
+		}
+	case AnchorSunEvent:
+		if !isSunEvent(anchor.SunEvent) {
+			errs.Add("anchor.sunEvent", "unknown sun event %q", anchor.SunEvent)
+		}
+	case AnchorClock:
+		if _, err := time.Parse("15:04", anchor.ClockTime); err != nil {
+			errs.Add("anchor.clockTime", "clock time must be HH:MM, got %q", anchor.ClockTime)
+		}
+	case AnchorJumuah:
+	default:
+		errs.Add("anchor.type", "unknown anchor type %q", anchor.Type)
+	}
+
+	if a.OffsetMinutes < -MaxAlarmOffsetMinutes || a.OffsetMinutes > MaxAlarmOffsetMinutes {
+		errs.Add("offsetMinutes", "offset must be between -%d and %d minutes, got %d",
+			MaxAlarmOffsetMinutes, MaxAlarmOffsetMinutes, a.OffsetMinutes)
+	}
+
+	seen := make(map[int]bool)
+	for _, day := range a.RepeatDays {
+		if day < 1 || day > 7 {
+			errs.Add("repeatDays", "repeat days must be between 1 (Monday) and 7 (Sunday), got %d", day)
+		} else if seen[day] {
+			errs.Add("repeatDays", "%s is repeated more than once", DayName(day))
+		}
+		seen[day] = true
+	}
+
+	return errs.Err()
+}
+
+// NewJumuahReminders creates the preset pre-Jumu'ah reminders for ghusl
+// and reading Surah al-Kahf.
+func NewJumuahReminders() []Alarm {
+	ghusl := NewAlarm(Jumuah, -60)
+	ghusl.Anchor = AlarmAnchor{Type: AnchorJumuah}
+	ghusl.Label = "Ghusl before Jumu'ah"
+
+	kahf := NewAlarm(Jumuah, -180)
+	kahf.Anchor = AlarmAnchor{Type: AnchorJumuah}
+	kahf.Label = "Read Surah al-Kahf"
+
+	return []Alarm{ghusl, kahf}
+}
+
 // GetActualAlarmTime calculates the actual alarm time by applying offset to prayer time.
 func (a *Alarm) GetActualAlarmTime(prayerTime time.Time) time.Time {
 	return prayerTime.Add(time.Duration(a.OffsetMinutes) * time.Minute)
@@ -62,8 +171,12 @@ func (a *Alarm) DisplayLabel() string {
 		return a.Label
 	}
 
+	anchor := a.EffectiveAnchor()
 	if a.OffsetMinutes == 0 {
-		return fmt.Sprintf("At %s time", a.Prayer.DisplayName())
+		if anchor.Type == AnchorPrayer {
+			return fmt.Sprintf("At %s time", anchor.DisplayName())
+		}
+		return fmt.Sprintf("At %s", anchor.DisplayName())
 	}
 
 	direction := "after"
@@ -73,7 +186,7 @@ func (a *Alarm) DisplayLabel() string {
 		offset = -offset
 	}
 
-	return fmt.Sprintf("%d min %s %s", offset, direction, a.Prayer.DisplayName())
+	return fmt.Sprintf("%d min %s %s", offset, direction, anchor.DisplayName())
 }
 
 // MarkUpdated updates the UpdatedAt timestamp.
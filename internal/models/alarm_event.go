@@ -0,0 +1,86 @@
+// Package models contains data model definitions for the AzanAlarm application.
+package models
+
+import "time"
+
+// AlarmEventType represents what happened to an alarm occurrence.
+type AlarmEventType string
+
+const (
+	AlarmFired     AlarmEventType = "fired"
+	AlarmSnoozed   AlarmEventType = "snoozed"
+	AlarmDismissed AlarmEventType = "dismissed"
+	AlarmMissed    AlarmEventType = "missed"
+)
+
+// AllAlarmEventTypes returns all alarm event types.
+func AllAlarmEventTypes() []AlarmEventType {
+	return []AlarmEventType{AlarmFired, AlarmSnoozed, AlarmDismissed, AlarmMissed}
+}
+
+// IsValid checks if the event type is one of the known types.
+func (t AlarmEventType) IsValid() bool {
+	for _, known := range AllAlarmEventTypes() {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// AlarmEvent represents an entry in the alarm firing history.
+type AlarmEvent struct {
+	ID          int            `json:"id"`
+	AlarmID     int            `json:"alarmId"`
+	Prayer      Prayer         `json:"prayer"`
+	Label       string         `json:"label"`
+	Type        AlarmEventType `json:"type"`
+	ScheduledAt string         `json:"scheduledAt"` // ISO 8601 time the alarm was due
+	RecordedAt  int64          `json:"recordedAt"`  // Unix timestamp in milliseconds
+}
+
+// AlarmEventFilter narrows down alarm history queries.
+// Zero values mean no filtering on that field.
+type AlarmEventFilter struct {
+	From    string           `json:"from"` // Inclusive date, YYYY-MM-DD
+	To      string           `json:"to"`   // Inclusive date, YYYY-MM-DD
+	Prayer  Prayer           `json:"prayer"`
+	AlarmID int              `json:"alarmId"`
+	Types   []AlarmEventType `json:"types"`
+}
+
+// Matches checks if an event satisfies the filter.
+func (f *AlarmEventFilter) Matches(event AlarmEvent) bool {
+	if f.Prayer != "" && event.Prayer != f.Prayer {
+		return false
+	}
+	if f.AlarmID != 0 && event.AlarmID != f.AlarmID {
+		return false
+	}
+	if len(f.Types) > 0 {
+		found := false
+		for _, t := range f.Types {
+			if t == event.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.From != "" || f.To != "" {
+		scheduled, err := time.Parse(time.RFC3339, event.ScheduledAt)
+		if err != nil {
+			return false
+		}
+		date := scheduled.Format("2006-01-02")
+		if f.From != "" && date < f.From {
+			return false
+		}
+		if f.To != "" && date > f.To {
+			return false
+		}
+	}
+	return true
+}
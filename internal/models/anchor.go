@@ -0,0 +1,131 @@
+// Package models contains data model definitions for the AzanAlarm application.
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// AnchorType represents the kind of event an alarm is anchored to.
+type AnchorType string
+
+const (
+	AnchorPrayer   AnchorType = "prayer"    // One of the five daily prayers
+	AnchorSunEvent AnchorType = "sun_event" // Sunrise, sunset and other solar events
+	AnchorClock    AnchorType = "clock"     // A fixed local clock time
+	AnchorIqamah   AnchorType = "iqamah"    // A prayer's iqamah at a mosque
+	AnchorJumuah   AnchorType = "jumuah"    // The Friday khutbah, never fires on other days
+)
+
+// SunEvent represents a solar event that is not a prayer time.
+type SunEvent string
+
+const (
+	Sunrise   SunEvent = "sunrise"
+	Sunset    SunEvent = "sunset"
+	SolarNoon SunEvent = "solar_noon"
+	Midnight  SunEvent = "midnight"   // Midpoint between sunset and the next sunrise
+	LastThird SunEvent = "last_third" // Start of the last third of the night, sunset to next Fajr
+)
+
+// AllSunEvents returns all sun events in order.
+func AllSunEvents() []SunEvent {
+	return []SunEvent{Sunrise, SolarNoon, Sunset, Midnight, LastThird}
+}
+
+// isSunEvent checks if the event is one of the known sun events.
+func isSunEvent(event SunEvent) bool {
+	for _, e := range AllSunEvents() {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// DisplayName returns the human-readable name for the sun event.
+func (e SunEvent) DisplayName() string {
+	switch e {
+	case Sunrise:
+		return "Sunrise"
+	case Sunset:
+		return "Sunset"
+	case SolarNoon:
+		return "Solar Noon"
+	case Midnight:
+		return "Midnight"
+	case LastThird:
+		return "Last Third of the Night"
+	default:
+		return string(e)
+	}
+}
+
+// AlarmAnchor describes the event an alarm is relative to. Only the field
+// matching Type is used.
+type AlarmAnchor struct {
+	Type      AnchorType `json:"type"`
+	Prayer    Prayer     `json:"prayer,omitempty"`
+	SunEvent  SunEvent   `json:"sunEvent,omitempty"`
+	ClockTime string     `json:"clockTime,omitempty"` // HH:MM in local time
+	MosqueID  int        `json:"mosqueId,omitempty"`  // Used with Prayer by iqamah anchors
+}
+
+// PrayerAnchor creates an anchor for one of the five daily prayers.
+func PrayerAnchor(prayer Prayer) AlarmAnchor {
+	return AlarmAnchor{Type: AnchorPrayer, Prayer: prayer}
+}
+
+// DisplayName returns a human-readable name for the anchor.
+func (a AlarmAnchor) DisplayName() string {
+	switch a.Type {
+	case AnchorPrayer:
+		return a.Prayer.DisplayName()
+	case AnchorSunEvent:
+		return a.SunEvent.DisplayName()
+	case AnchorClock:
+		return a.ClockTime
+	case AnchorIqamah:
+		return a.Prayer.DisplayName() + " Iqamah"
+	case AnchorJumuah:
+		return Jumuah.DisplayName()
+	default:
+		return string(a.Type)
+	}
+}
+
+// ClockTimeOn returns the clock time of a clock anchor on the given date.
+func (a AlarmAnchor) ClockTimeOn(date time.Time) (time.Time, error) {
+	t, err := time.Parse("15:04", a.ClockTime)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid clock time %q", a.ClockTime)
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), 0, 0, date.Location()), nil
+}
+
+// SunTimes represents the solar events for a specific day.
+type SunTimes struct {
+	Sunrise   string `json:"sunrise"`   // ISO 8601 time string
+	SolarNoon string `json:"solarNoon"` // ISO 8601 time string
+	Sunset    string `json:"sunset"`    // ISO 8601 time string
+	Midnight  string `json:"midnight"`  // ISO 8601 time string, may fall on the next date
+	LastThird string `json:"lastThird"` // ISO 8601 time string, may fall on the next date
+}
+
+// GetTime returns the time for a specific sun event.
+func (st *SunTimes) GetTime(event SunEvent) string {
+	switch event {
+	case Sunrise:
+		return st.Sunrise
+	case SolarNoon:
+		return st.SolarNoon
+	case Sunset:
+		return st.Sunset
+	case Midnight:
+		return st.Midnight
+	case LastThird:
+		return st.LastThird
+	default:
+		return ""
+	}
+}
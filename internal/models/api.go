@@ -0,0 +1,43 @@
+// Package models contains data model definitions for the AzanAlarm application.
+package models
+
+import (
+	"net"
+	"strconv"
+)
+
+// DefaultAPIAddress is where the local API listens unless configured
+// otherwise. It only accepts connections from this machine.
+const DefaultAPIAddress = "127.0.0.1:8765"
+
+// MinAPITokenLength is the shortest API token accepted.
+const MinAPITokenLength = 16
+
+// APISettings configures the local HTTP API.
+type APISettings struct {
+	Enabled bool   `json:"enabled"`
+	Address string `json:"address"` // host:port to listen on; empty uses DefaultAPIAddress
+	Token   string `json:"token"`   // Required as a bearer token on every request
+}
+
+// ListenAddress returns the address to listen on.
+func (s APISettings) ListenAddress() string {
+	if s.Address == "" {
+		return DefaultAPIAddress
+	}
+	return s.Address
+}
+
+// validate checks the address and token, recording problems under the
+// "api" prefix.
+func (s APISettings) validate(errs *ValidationErrors) {
+	if s.Address != "" {
+		_, port, err := net.SplitHostPort(s.Address)
+		if n, perr := strconv.Atoi(port); err != nil || perr != nil || n < 0 || n > 65535 {
+			errs.Add("api.address", "address must be host:port, got %q", s.Address)
+		}
+	}
+	if s.Token != "" && len(s.Token) < MinAPITokenLength {
+		errs.Add("api.token", "token must be at least %d characters", MinAPITokenLength)
+	}
+}
@@ -0,0 +1,82 @@
+// Package models contains data model definitions for the AzanAlarm application.
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// BackupFormat identifies AzanAlarm backup files.
+const BackupFormat = "azanalarm-backup"
+
+// BackupVersion is the version of the backup file layout written by
+// ExportBackup. It is independent of the storage schema version.
+const BackupVersion = 1
+
+// RestoreMode selects how a backup is combined with the existing data.
+type RestoreMode string
+
+const (
+	RestoreMerge   RestoreMode = "merge"   // Add what is missing, keep existing items
+	RestoreReplace RestoreMode = "replace" // Discard existing items in favour of the backup
+)
+
+// IsValid checks if the mode is one of the known restore modes.
+func (m RestoreMode) IsValid() bool {
+	return m == RestoreMerge || m == RestoreReplace
+}
+
+// Backup is the file written by ExportBackup. Data holds a BackupData
+// value; Checksum is the SHA-256 of Data in compact form, so edits to the
+// file are detected.
+type Backup struct {
+	Format        string          `json:"format"`        // Always BackupFormat
+	Version       int             `json:"version"`       // BackupVersion when written
+	SchemaVersion int             `json:"schemaVersion"` // Storage schema of the items in Data
+	CreatedAt     string          `json:"createdAt"`     // ISO 8601 time string
+	Checksum      string          `json:"checksum"`      // "sha256:" followed by the hex digest
+	Data          json.RawMessage `json:"data"`
+}
+
+// BackupData is the user data bundled into a backup. AlarmProfiles and
+// Timetables are nil in backups made before they were included.
+type BackupData struct {
+	Settings        *AppSettings     `json:"settings,omitempty"`
+	CurrentLocation *Location        `json:"currentLocation,omitempty"`
+	SavedLocations  []Location       `json:"savedLocations"`
+	Mosques         []Mosque         `json:"mosques"`
+	Alarms          []Alarm          `json:"alarms"`
+	AlarmProfiles   []AlarmProfile   `json:"alarmProfiles"`
+	Timetables      []Timetable      `json:"timetables"`
+	PrayerLog       []PrayerLogEntry `json:"prayerLog"`
+	AlarmEvents     []AlarmEvent     `json:"alarmEvents"`
+	Qada            *QadaLedger      `json:"qada,omitempty"`
+}
+
+// RestoreCounts reports what a restore changed in one kind of data.
+type RestoreCounts struct {
+	Section   string `json:"section"` // e.g. "alarms"
+	Added     int    `json:"added"`
+	Updated   int    `json:"updated"`
+	Removed   int    `json:"removed"`
+	Unchanged int    `json:"unchanged"` // Already present, or kept in merge mode
+}
+
+// RestoreSummary reports the outcome of ImportBackup.
+type RestoreSummary struct {
+	Mode            RestoreMode     `json:"mode"`
+	BackupCreatedAt string          `json:"backupCreatedAt"` // ISO 8601 time string
+	Sections        []RestoreCounts `json:"sections"`
+}
+
+// String formats the summary for logs and the command line.
+func (s RestoreSummary) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Restored backup from %s (%s)", s.BackupCreatedAt, s.Mode)
+	for _, c := range s.Sections {
+		fmt.Fprintf(&b, "\n  %-16s %d added, %d updated, %d removed, %d unchanged",
+			c.Section+":", c.Added, c.Updated, c.Removed, c.Unchanged)
+	}
+	return b.String()
+}
@@ -0,0 +1,36 @@
+// Package models contains data model definitions for the AzanAlarm application.
+package models
+
+// PrayerDeviation measures how far calculated times for one prayer are
+// from an official timetable.
+type PrayerDeviation struct {
+	Prayer            Prayer  `json:"prayer"`
+	MeanMinutes       float64 `json:"meanMinutes"`       // Mean absolute deviation
+	MaxMinutes        int     `json:"maxMinutes"`        // Largest absolute deviation
+	MeanSignedMinutes float64 `json:"meanSignedMinutes"` // Mean of official minus calculated
+}
+
+// MethodComparison holds the deviation of one calculation method and
+// juristic school from an official timetable.
+type MethodComparison struct {
+	Rank           int                     `json:"rank"`
+	Method         PrayerCalculationMethod `json:"method"`
+	MethodName     string                  `json:"methodName"`
+	JuristicMethod JuristicMethod          `json:"juristicMethod"`
+	MeanMinutes    float64                 `json:"meanMinutes"` // Mean absolute deviation over all prayers
+	MaxMinutes     int                     `json:"maxMinutes"`  // Largest absolute deviation over all prayers
+	Prayers        []PrayerDeviation       `json:"prayers"`
+}
+
+// MethodComparisonReport ranks every calculation method against an
+// official timetable and recommends the best fit.
+type MethodComparisonReport struct {
+	TimetableID   int                `json:"timetableId"`
+	TimetableName string             `json:"timetableName"`
+	From          string             `json:"from"` // YYYY-MM-DD
+	To            string             `json:"to"`   // YYYY-MM-DD
+	Dates         int                `json:"dates"`
+	Results       []MethodComparison `json:"results"` // Best fit first
+	Recommended   MethodComparison   `json:"recommended"`
+	Adjustments   map[Prayer]int     `json:"adjustments"` // Minutes to add to the recommended method's times
+}
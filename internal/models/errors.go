@@ -0,0 +1,126 @@
+// Package models contains data model definitions for the AzanAlarm application.
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorCode classifies an error so the UI can react to it.
+type ErrorCode string
+
+const (
+	CodeNotConfigured      ErrorCode = "not_configured"      // Required setup, such as a location, is missing
+	CodeNotFound           ErrorCode = "not_found"           // The referenced item does not exist
+	CodeValidation         ErrorCode = "validation"          // The input was rejected
+	CodeStorageUnavailable ErrorCode = "storage_unavailable" // Data could not be read or written
+	CodeNetwork            ErrorCode = "network"             // A remote service could not be reached
+	CodeUnauthorized       ErrorCode = "unauthorized"        // The API token was missing or wrong
+	CodeInternal           ErrorCode = "internal"            // Anything not covered above
+)
+
+// Sentinel errors for use with errors.Is. They match any AppError with the
+// same code.
+var (
+	ErrNotConfigured      = &AppError{Code: CodeNotConfigured}
+	ErrNotFound           = &AppError{Code: CodeNotFound}
+	ErrValidation         = &AppError{Code: CodeValidation}
+	ErrStorageUnavailable = &AppError{Code: CodeStorageUnavailable}
+	ErrNetwork            = &AppError{Code: CodeNetwork}
+)
+
+// AppError is an error returned from the App bindings.
+type AppError struct {
+	Code    ErrorCode    `json:"code"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"` // Per-field details of validation errors
+	Err     error        `json:"-"`                // Underlying cause, if any
+}
+
+// FieldError describes why one input field was rejected. Field is the JSON
+// path of the field, such as "repeatDays" or "jumuah.khutbahTime".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors collects field errors while validating a value.
+type ValidationErrors []FieldError
+
+// Add records a problem with a field.
+func (v *ValidationErrors) Add(field, format string, args ...interface{}) {
+	*v = append(*v, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Err returns a validation error carrying the collected fields, or nil if
+// nothing was recorded.
+func (v ValidationErrors) Err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	message := v[0].Message
+	if len(v) > 1 {
+		message = fmt.Sprintf("%s (and %d more)", message, len(v)-1)
+	}
+	return &AppError{Code: CodeValidation, Message: message, Fields: v}
+}
+
+// Error returns the error message.
+func (e *AppError) Error() string {
+	if e.Message == "" && e.Err != nil {
+		return e.Err.Error()
+	}
+	if e.Message == "" {
+		return string(e.Code)
+	}
+	return e.Message
+}
+
+// Unwrap returns the underlying cause.
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is a sentinel with the same code.
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	return ok && t.Message == "" && t.Err == nil && t.Code == e.Code
+}
+
+// NotConfiguredError creates a not-configured error.
+func NotConfiguredError(format string, args ...interface{}) *AppError {
+	return &AppError{Code: CodeNotConfigured, Message: fmt.Sprintf(format, args...)}
+}
+
+// NotFoundError creates a not-found error.
+func NotFoundError(format string, args ...interface{}) *AppError {
+	return &AppError{Code: CodeNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+// ValidationError creates a validation error.
+func ValidationError(format string, args ...interface{}) *AppError {
+	return &AppError{Code: CodeValidation, Message: fmt.Sprintf(format, args...)}
+}
+
+// StorageError wraps a failure to read or write application data.
+func StorageError(err error) *AppError {
+	return &AppError{Code: CodeStorageUnavailable, Message: "storage unavailable: " + err.Error(), Err: err}
+}
+
+// NetworkError wraps a failure to reach a remote service.
+func NetworkError(err error) *AppError {
+	return &AppError{Code: CodeNetwork, Message: "network error: " + err.Error(), Err: err}
+}
+
+// AsAppError returns err as an AppError, classifying unknown errors as
+// internal. It returns nil for a nil error.
+func AsAppError(err error) *AppError {
+	if err == nil {
+		return nil
+	}
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return &AppError{Code: CodeInternal, Message: err.Error(), Err: err}
+}
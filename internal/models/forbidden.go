@@ -0,0 +1,40 @@
+// Package models contains data model definitions for the AzanAlarm application.
+package models
+
+// ForbiddenKind represents one of the times when voluntary prayer is disliked.
+type ForbiddenKind string
+
+const (
+	ForbiddenSunrise ForbiddenKind = "sunrise" // From sunrise until the sun is a spear's length high
+	ForbiddenZenith  ForbiddenKind = "zenith"  // Just before the sun passes its highest point
+	ForbiddenSunset  ForbiddenKind = "sunset"  // From the sun yellowing until sunset
+)
+
+// DisplayName returns the human-readable name for the forbidden window.
+func (k ForbiddenKind) DisplayName() string {
+	switch k {
+	case ForbiddenSunrise:
+		return "After Sunrise"
+	case ForbiddenZenith:
+		return "Zenith"
+	case ForbiddenSunset:
+		return "Before Sunset"
+	default:
+		return string(k)
+	}
+}
+
+// ForbiddenWindow is a period of a day in which voluntary prayer is makruh.
+type ForbiddenWindow struct {
+	Kind  ForbiddenKind `json:"kind"`
+	Start string        `json:"start"` // ISO 8601 time string
+	End   string        `json:"end"`   // ISO 8601 time string
+}
+
+// ForbiddenWindowSettings holds the length of each forbidden window in
+// minutes. A length of zero leaves the window out.
+type ForbiddenWindowSettings struct {
+	SunriseMinutes int `json:"sunriseMinutes"` // After sunrise
+	ZenithMinutes  int `json:"zenithMinutes"`  // Before Dhuhr
+	SunsetMinutes  int `json:"sunsetMinutes"`  // Before sunset
+}
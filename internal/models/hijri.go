@@ -0,0 +1,66 @@
+// Package models contains data model definitions for the AzanAlarm application.
+package models
+
+import "time"
+
+// HijriDate represents a date in the Islamic (Hijri) calendar.
+type HijriDate struct {
+	Year  int `json:"year"`
+	Month int `json:"month"` // 1=Muharram, 12=Dhu al-Hijjah
+	Day   int `json:"day"`
+}
+
+// ToHijri converts a Gregorian date to the tabular Islamic calendar.
+// The tabular calendar may differ by a day from local moon sighting.
+func ToHijri(date time.Time) HijriDate {
+	y, m, d := date.Date()
+
+	// Julian day number of the Gregorian date
+	a := (14 - int(m)) / 12
+	yy := y + 4800 - a
+	mm := int(m) + 12*a - 3
+	jdn := d + (153*mm+2)/5 + 365*yy + yy/4 - yy/100 + yy/400 - 32045
+
+	l := jdn - 1948440 + 10632
+	n := (l - 1) / 10631
+	l = l - 10631*n + 354
+	j := ((10985-l)/5316)*((50*l)/17719) + (l/5670)*((43*l)/15238)
+	l = l - ((30-j)/15)*((17719*j)/50) - (j/16)*((15238*j)/43) + 29
+	month := (24 * l) / 709
+	day := l - (709*month)/24
+	year := 30*n + j - 30
+
+	return HijriDate{Year: year, Month: month, Day: day}
+}
+
+// HijriMonthName returns the name of a Hijri month given its number (1-12).
+func HijriMonthName(month int) string {
+	switch month {
+	case 1:
+		return "Muharram"
+	case 2:
+		return "Safar"
+	case 3:
+		return "Rabi al-Awwal"
+	case 4:
+		return "Rabi al-Thani"
+	case 5:
+		return "Jumada al-Awwal"
+	case 6:
+		return "Jumada al-Thani"
+	case 7:
+		return "Rajab"
+	case 8:
+		return "Sha'ban"
+	case 9:
+		return "Ramadan"
+	case 10:
+		return "Shawwal"
+	case 11:
+		return "Dhu al-Qi'dah"
+	case 12:
+		return "Dhu al-Hijjah"
+	default:
+		return ""
+	}
+}
@@ -1,7 +1,11 @@
 // Package models contains data model definitions for the AzanAlarm application.
 package models
 
-import "time"
+import (
+	"fmt"
+	"math"
+	"time"
+)
 
 // Location represents a geographic location for prayer time calculations.
 type Location struct {
@@ -28,11 +32,35 @@ func NewLocation(name, country string, lat, lon float64, timezone string) Locati
 	}
 }
 
+// Validate checks that the coordinates are on the globe and that the
+// timezone, if set, is known.
+func (l *Location) Validate() error {
+	var errs ValidationErrors
+	if math.IsNaN(l.Latitude) || l.Latitude < -90 || l.Latitude > 90 {
+		errs.Add("latitude", "latitude must be between -90 and 90, got %v", l.Latitude)
+	}
+	if math.IsNaN(l.Longitude) || l.Longitude < -180 || l.Longitude > 180 {
+		errs.Add("longitude", "longitude must be between -180 and 180, got %v", l.Longitude)
+	}
+	if l.Timezone != "" {
+		if _, err := time.LoadLocation(l.Timezone); err != nil {
+			errs.Add("timezone", "unknown timezone %q", l.Timezone)
+		}
+	}
+	return errs.Err()
+}
+
 // DisplayName returns a formatted display name for the location.
 func (l *Location) DisplayName() string {
 	return l.Name + ", " + l.Country
 }
 
+// SyncKey identifies the location across synced devices by its
+// coordinates, since saved locations are never edited.
+func (l *Location) SyncKey() string {
+	return fmt.Sprintf("%.6f,%.6f", l.Latitude, l.Longitude)
+}
+
 // IsSameLocation checks if two locations are at the same coordinates.
 func (l *Location) IsSameLocation(other Location) bool {
 	return l.Latitude == other.Latitude && l.Longitude == other.Longitude
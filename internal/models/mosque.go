@@ -0,0 +1,138 @@
+// Package models contains data model definitions for the AzanAlarm application.
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// IqamahMode represents how a mosque sets the iqamah for a prayer.
+type IqamahMode string
+
+const (
+	IqamahFixed      IqamahMode = "fixed"       // Fixed local clock time
+	IqamahAfterAdhan IqamahMode = "after_adhan" // A number of minutes after the adhan
+)
+
+// IqamahOverride replaces the iqamah time on one day of the week.
+type IqamahOverride struct {
+	Weekday int    `json:"weekday"` // 1=Monday, 7=Sunday
+	Time    string `json:"time"`    // HH:MM in local time
+}
+
+// IqamahRule describes when the congregation starts for a prayer.
+type IqamahRule struct {
+	Mode         IqamahMode       `json:"mode"`
+	Time         string           `json:"time"`         // HH:MM, used by the fixed mode
+	MinutesAfter int              `json:"minutesAfter"` // Used by the after_adhan mode
+	Overrides    []IqamahOverride `json:"overrides"`    // Weekly overrides, take precedence over the mode
+}
+
+// Validate checks that the rule can be resolved to a time.
+func (r *IqamahRule) Validate() error {
+	switch r.Mode {
+	case IqamahFixed:
+		if _, err := time.Parse("15:04", r.Time); err != nil {
+			return fmt.Errorf("invalid iqamah time %q", r.Time)
+		}
+	case IqamahAfterAdhan:
+		if r.MinutesAfter < 0 || r.MinutesAfter > 180 {
+			return fmt.Errorf("minutes after adhan must be between 0 and 180, got %d", r.MinutesAfter)
+		}
+	default:
+		return fmt.Errorf("unknown iqamah mode %q", r.Mode)
+	}
+	for _, o := range r.Overrides {
+		if o.Weekday < 1 || o.Weekday > 7 {
+			return fmt.Errorf("invalid override weekday %d", o.Weekday)
+		}
+		if _, err := time.Parse("15:04", o.Time); err != nil {
+			return fmt.Errorf("invalid override time %q", o.Time)
+		}
+	}
+	return nil
+}
+
+// TimeOn returns the iqamah time on the given date for a prayer whose adhan
+// is at the given time.
+func (r *IqamahRule) TimeOn(date time.Time, adhan time.Time) (time.Time, bool) {
+	weekday := int(date.Weekday())
+	if weekday == 0 {
+		weekday = 7 // Convert Sunday from 0 to 7
+	}
+	for _, o := range r.Overrides {
+		if o.Weekday == weekday {
+			return clockTimeOn(o.Time, date)
+		}
+	}
+
+	switch r.Mode {
+	case IqamahFixed:
+		return clockTimeOn(r.Time, date)
+	case IqamahAfterAdhan:
+		return adhan.Add(time.Duration(r.MinutesAfter) * time.Minute), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// JumuahSlot holds a mosque's Friday prayer times.
+type JumuahSlot struct {
+	KhutbahTime string `json:"khutbahTime"` // HH:MM in local time
+	IqamahTime  string `json:"iqamahTime"`  // HH:MM in local time
+}
+
+// IsSet checks if the mosque holds Jumu'ah.
+func (j *JumuahSlot) IsSet() bool {
+	return j.IqamahTime != ""
+}
+
+// Mosque represents a mosque with its iqamah schedule.
+type Mosque struct {
+	ID        int                   `json:"id"`
+	Name      string                `json:"name"`
+	Address   string                `json:"address"`
+	Rules     map[Prayer]IqamahRule `json:"rules"`
+	Jumuah    JumuahSlot            `json:"jumuah"`
+	IsDefault bool                  `json:"isDefault"`
+	CreatedAt int64                 `json:"createdAt"` // Unix timestamp in milliseconds
+	UpdatedAt int64                 `json:"updatedAt"` // Unix timestamp in milliseconds
+}
+
+// Clone returns a copy of the mosque that shares no memory with it.
+func (m Mosque) Clone() Mosque {
+	if m.Rules != nil {
+		rules := make(map[Prayer]IqamahRule, len(m.Rules))
+		for p, r := range m.Rules {
+			if r.Overrides != nil {
+				r.Overrides = append([]IqamahOverride{}, r.Overrides...)
+			}
+			rules[p] = r
+		}
+		m.Rules = rules
+	}
+	return m
+}
+
+// IqamahTime returns the iqamah time for a prayer on the given date, where
+// adhan is the calculated prayer time. On Fridays the Jumu'ah slot replaces
+// Dhuhr when the mosque holds Jumu'ah.
+func (m *Mosque) IqamahTime(prayer Prayer, date time.Time, adhan time.Time) (time.Time, bool) {
+	if prayer == Dhuhr && date.Weekday() == time.Friday && m.Jumuah.IsSet() {
+		return clockTimeOn(m.Jumuah.IqamahTime, date)
+	}
+	rule, ok := m.Rules[prayer]
+	if !ok {
+		return time.Time{}, false
+	}
+	return rule.TimeOn(date, adhan)
+}
+
+// clockTimeOn returns an HH:MM clock time on the given date in local time.
+func clockTimeOn(clock string, date time.Time) (time.Time, bool) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), 0, 0, time.Local), true
+}
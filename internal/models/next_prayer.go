@@ -0,0 +1,30 @@
+// Package models contains data model definitions for the AzanAlarm application.
+package models
+
+// NextPrayerReason explains why no next prayer could be determined.
+type NextPrayerReason string
+
+const (
+	NextPrayerNoLocation    NextPrayerReason = "no_location"     // No current location is set
+	NextPrayerNoPrayerTimes NextPrayerReason = "no_prayer_times" // Times could not be calculated, e.g. in polar regions
+)
+
+// NextPrayer describes the prayer window the user is in and the prayer
+// that follows it. A prayer's window runs until the next prayer starts.
+type NextPrayer struct {
+	Available bool             `json:"available"`
+	Reason    NextPrayerReason `json:"reason,omitempty"` // Set when Available is false
+
+	CurrentPrayer  Prayer  `json:"currentPrayer"`
+	CurrentStart   string  `json:"currentStart"`   // ISO 8601 time string
+	CurrentEnd     string  `json:"currentEnd"`     // ISO 8601 time string, equal to Time
+	ElapsedPercent float64 `json:"elapsedPercent"` // Share of the current window that has passed, 0-100
+
+	Prayer           Prayer `json:"prayer"`
+	Time             string `json:"time"` // ISO 8601 time string
+	RemainingSeconds int    `json:"remainingSeconds"`
+
+	Mosque                 string `json:"mosque,omitempty"`     // Default mosque, if it has an iqamah for the prayer
+	IqamahTime             string `json:"iqamahTime,omitempty"` // ISO 8601 time string
+	IqamahRemainingSeconds int    `json:"iqamahRemainingSeconds,omitempty"`
+}
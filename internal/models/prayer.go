@@ -1,6 +1,8 @@
 // Package models contains data model definitions for the AzanAlarm application.
 package models
 
+import "time"
+
 // Prayer represents the five daily prayers.
 type Prayer string
 
@@ -10,6 +12,10 @@ const (
 	Asr     Prayer = "asr"
 	Maghrib Prayer = "maghrib"
 	Isha    Prayer = "isha"
+
+	// Jumuah replaces Dhuhr on Fridays. It is not one of the five daily
+	// prayers returned by AllPrayers.
+	Jumuah Prayer = "jumuah"
 )
 
 // AllPrayers returns a slice of all prayer types in order.
@@ -17,6 +23,16 @@ func AllPrayers() []Prayer {
 	return []Prayer{Fajr, Dhuhr, Asr, Maghrib, Isha}
 }
 
+// isDailyPrayer checks if the prayer is one of the five daily prayers.
+func isDailyPrayer(prayer Prayer) bool {
+	for _, p := range AllPrayers() {
+		if p == prayer {
+			return true
+		}
+	}
+	return false
+}
+
 // DisplayName returns the human-readable name for the prayer.
 func (p Prayer) DisplayName() string {
 	switch p {
@@ -30,6 +46,8 @@ func (p Prayer) DisplayName() string {
 		return "Maghrib"
 	case Isha:
 		return "Isha"
+	case Jumuah:
+		return "Jumu'ah"
 	default:
 		return string(p)
 	}
@@ -158,6 +176,7 @@ type PrayerTimes struct {
 	Asr     string `json:"asr"`     // ISO 8601 time string
 	Maghrib string `json:"maghrib"` // ISO 8601 time string
 	Isha    string `json:"isha"`    // ISO 8601 time string
+	Jumuah  string `json:"jumuah"`  // ISO 8601 khutbah time, only set on Fridays
 }
 
 // GetTime returns the prayer time for a specific prayer.
@@ -173,7 +192,41 @@ func (pt *PrayerTimes) GetTime(prayer Prayer) string {
 		return pt.Maghrib
 	case Isha:
 		return pt.Isha
+	case Jumuah:
+		return pt.Jumuah
 	default:
 		return ""
 	}
 }
+
+// SetTime sets the prayer time for a specific prayer.
+func (pt *PrayerTimes) SetTime(prayer Prayer, value string) {
+	switch prayer {
+	case Fajr:
+		pt.Fajr = value
+	case Dhuhr:
+		pt.Dhuhr = value
+	case Asr:
+		pt.Asr = value
+	case Maghrib:
+		pt.Maghrib = value
+	case Isha:
+		pt.Isha = value
+	case Jumuah:
+		pt.Jumuah = value
+	}
+}
+
+// ParseTime returns the parsed time for a specific prayer.
+// The second return value is false if the time is missing or malformed.
+func (pt *PrayerTimes) ParseTime(prayer Prayer) (time.Time, bool) {
+	value := pt.GetTime(prayer)
+	if value == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
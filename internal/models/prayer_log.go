@@ -0,0 +1,106 @@
+// Package models contains data model definitions for the AzanAlarm application.
+package models
+
+// PrayerStatus represents how a prayer was performed.
+type PrayerStatus string
+
+const (
+	PrayedOnTime       PrayerStatus = "on_time"
+	PrayedLate         PrayerStatus = "late"
+	PrayedCongregation PrayerStatus = "congregation"
+	PrayerMissed       PrayerStatus = "missed"
+)
+
+// AllPrayerStatuses returns all prayer statuses.
+func AllPrayerStatuses() []PrayerStatus {
+	return []PrayerStatus{PrayedOnTime, PrayedLate, PrayedCongregation, PrayerMissed}
+}
+
+// IsValid checks if the status is one of the known statuses.
+func (s PrayerStatus) IsValid() bool {
+	for _, known := range AllPrayerStatuses() {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPerformed checks if the prayer was performed at all.
+func (s PrayerStatus) IsPerformed() bool {
+	return s == PrayedOnTime || s == PrayedLate || s == PrayedCongregation
+}
+
+// IsOnTime checks if the prayer was performed within its time.
+// Prayers in congregation are counted as on time.
+func (s PrayerStatus) IsOnTime() bool {
+	return s == PrayedOnTime || s == PrayedCongregation
+}
+
+// DisplayName returns a human-readable name for the status.
+func (s PrayerStatus) DisplayName() string {
+	switch s {
+	case PrayedOnTime:
+		return "On time"
+	case PrayedLate:
+		return "Late"
+	case PrayedCongregation:
+		return "In congregation"
+	case PrayerMissed:
+		return "Missed"
+	default:
+		return string(s)
+	}
+}
+
+// PrayerLogEntry records how a single prayer was performed on a given day.
+type PrayerLogEntry struct {
+	Date      string       `json:"date"` // YYYY-MM-DD
+	Prayer    Prayer       `json:"prayer"`
+	Status    PrayerStatus `json:"status"`
+	UpdatedAt int64        `json:"updatedAt"` // Unix timestamp in milliseconds
+}
+
+// StatsWindow represents the period that prayer statistics cover.
+type StatsWindow string
+
+const (
+	StatsWeek  StatsWindow = "week"
+	StatsMonth StatsWindow = "month"
+	StatsYear  StatsWindow = "year"
+)
+
+// Days returns the number of days covered by the window, including today.
+func (w StatsWindow) Days() int {
+	switch w {
+	case StatsWeek:
+		return 7
+	case StatsMonth:
+		return 30
+	case StatsYear:
+		return 365
+	default:
+		return 0
+	}
+}
+
+// PrayerStat holds the counts for a single prayer over a stats window.
+type PrayerStat struct {
+	Prayer        Prayer  `json:"prayer"`
+	Logged        int     `json:"logged"`
+	OnTime        int     `json:"onTime"`
+	Late          int     `json:"late"`
+	Congregation  int     `json:"congregation"`
+	Missed        int     `json:"missed"`
+	OnTimePercent float64 `json:"onTimePercent"` // Share of logged prayers performed on time
+}
+
+// PrayerStats summarises the prayer log over a stats window.
+type PrayerStats struct {
+	Window        StatsWindow  `json:"window"`
+	From          string       `json:"from"`          // YYYY-MM-DD
+	To            string       `json:"to"`            // YYYY-MM-DD
+	CurrentStreak int          `json:"currentStreak"` // Consecutive days with all five prayers performed
+	LongestStreak int          `json:"longestStreak"` // Longest streak ever recorded
+	Prayers       []PrayerStat `json:"prayers"`
+}
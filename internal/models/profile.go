@@ -0,0 +1,148 @@
+// Package models contains data model definitions for the AzanAlarm application.
+package models
+
+import "time"
+
+// AlarmProfile groups alarms that are switched on together, e.g. "Work".
+type AlarmProfile struct {
+	ID           int            `json:"id"`
+	Name         string         `json:"name"`
+	AlarmIDs     []int          `json:"alarmIds"`
+	IsActive     bool           `json:"isActive"`
+	AutoActivate ProfileTrigger `json:"autoActivate"`
+	CreatedAt    int64          `json:"createdAt"` // Unix timestamp in milliseconds
+	UpdatedAt    int64          `json:"updatedAt"` // Unix timestamp in milliseconds
+}
+
+// Clone returns a copy of the profile that shares no memory with it.
+func (p AlarmProfile) Clone() AlarmProfile {
+	if p.AlarmIDs != nil {
+		p.AlarmIDs = append([]int{}, p.AlarmIDs...)
+	}
+	if p.AutoActivate.Weekdays != nil {
+		p.AutoActivate.Weekdays = append([]int{}, p.AutoActivate.Weekdays...)
+	}
+	if p.AutoActivate.HijriMonths != nil {
+		p.AutoActivate.HijriMonths = append([]int{}, p.AutoActivate.HijriMonths...)
+	}
+	return p
+}
+
+// Validate checks the name and trigger of a profile. It does not check
+// that the referenced alarms and location exist, which is up to the caller.
+func (p *AlarmProfile) Validate() error {
+	var errs ValidationErrors
+	if p.Name == "" {
+		errs.Add("name", "profile name is required")
+	}
+	seen := make(map[int]bool)
+	for _, id := range p.AlarmIDs {
+		if seen[id] {
+			errs.Add("alarmIds", "alarm %d is listed more than once", id)
+		}
+		seen[id] = true
+	}
+	p.AutoActivate.validate(&errs)
+	return errs.Err()
+}
+
+// HasAlarm checks if the alarm is a member of the profile.
+func (p *AlarmProfile) HasAlarm(id int) bool {
+	for _, alarmID := range p.AlarmIDs {
+		if alarmID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ProfileTrigger describes when a profile activates automatically.
+// Every condition that is set must hold; a trigger without conditions never fires.
+type ProfileTrigger struct {
+	Weekdays    []int `json:"weekdays"`    // 1=Monday, 7=Sunday
+	LocationID  int   `json:"locationId"`  // Saved location ID, 0 for any
+	HijriMonths []int `json:"hijriMonths"` // 1=Muharram, 9=Ramadan
+}
+
+// validate records problems with the trigger conditions.
+func (t ProfileTrigger) validate(errs *ValidationErrors) {
+	seen := make(map[int]bool)
+	for _, day := range t.Weekdays {
+		if day < 1 || day > 7 {
+			errs.Add("autoActivate.weekdays", "weekdays must be between 1 (Monday) and 7 (Sunday), got %d", day)
+		} else if seen[day] {
+			errs.Add("autoActivate.weekdays", "%s is repeated more than once", DayName(day))
+		}
+		seen[day] = true
+	}
+	if t.LocationID < 0 {
+		errs.Add("autoActivate.locationId", "invalid location ID %d", t.LocationID)
+	}
+	seen = make(map[int]bool)
+	for _, month := range t.HijriMonths {
+		if month < 1 || month > 12 {
+			errs.Add("autoActivate.hijriMonths", "Hijri months must be between 1 (Muharram) and 12 (Dhu al-Hijjah), got %d", month)
+		} else if seen[month] {
+			errs.Add("autoActivate.hijriMonths", "Hijri month %d is repeated more than once", month)
+		}
+		seen[month] = true
+	}
+}
+
+// IsEmpty checks if the trigger has no conditions.
+func (t *ProfileTrigger) IsEmpty() bool {
+	return len(t.Weekdays) == 0 && t.LocationID == 0 && len(t.HijriMonths) == 0
+}
+
+// Specificity returns the number of conditions set, used to pick between
+// several matching profiles.
+func (t *ProfileTrigger) Specificity() int {
+	count := 0
+	if len(t.Weekdays) > 0 {
+		count++
+	}
+	if t.LocationID != 0 {
+		count++
+	}
+	if len(t.HijriMonths) > 0 {
+		count++
+	}
+	return count
+}
+
+// Matches checks if the trigger holds on the given date. The location
+// condition is checked by comparing against the current location, which
+// may be nil if none is set.
+func (t *ProfileTrigger) Matches(date time.Time, savedLocation, currentLocation *Location) bool {
+	if t.IsEmpty() {
+		return false
+	}
+	if len(t.Weekdays) > 0 {
+		weekday := int(date.Weekday())
+		if weekday == 0 {
+			weekday = 7 // Convert Sunday from 0 to 7
+		}
+		if !containsInt(t.Weekdays, weekday) {
+			return false
+		}
+	}
+	if t.LocationID != 0 {
+		if savedLocation == nil || currentLocation == nil || !savedLocation.IsSameLocation(*currentLocation) {
+			return false
+		}
+	}
+	if len(t.HijriMonths) > 0 && !containsInt(t.HijriMonths, ToHijri(date).Month) {
+		return false
+	}
+	return true
+}
+
+// containsInt checks if a slice contains a value.
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+// Package models contains data model definitions for the AzanAlarm application.
+package models
+
+// QadaLedger tracks makeup (qada) prayers owed for each prayer.
+type QadaLedger struct {
+	Outstanding  map[Prayer]int `json:"outstanding"`
+	Completed    map[Prayer]int `json:"completed"`
+	DailyGoal    int            `json:"dailyGoal"`    // Makeup prayers of each kind planned per day
+	SyncedMissed []string       `json:"syncedMissed"` // Prayer log entries already counted, as "date/prayer"
+	UpdatedAt    int64          `json:"updatedAt"`    // Unix timestamp in milliseconds
+}
+
+// NewQadaLedger creates an empty ledger with a goal of one makeup per prayer a day.
+func NewQadaLedger() QadaLedger {
+	ledger := QadaLedger{
+		Outstanding:  make(map[Prayer]int),
+		Completed:    make(map[Prayer]int),
+		DailyGoal:    1,
+		SyncedMissed: []string{},
+	}
+	for _, p := range AllPrayers() {
+		ledger.Outstanding[p] = 0
+		ledger.Completed[p] = 0
+	}
+	return ledger
+}
+
+// Clone returns a copy of the ledger that shares no memory with it.
+func (l QadaLedger) Clone() QadaLedger {
+	outstanding := make(map[Prayer]int, len(l.Outstanding))
+	for p, count := range l.Outstanding {
+		outstanding[p] = count
+	}
+	completed := make(map[Prayer]int, len(l.Completed))
+	for p, count := range l.Completed {
+		completed[p] = count
+	}
+	l.Outstanding = outstanding
+	l.Completed = completed
+	l.SyncedMissed = append([]string{}, l.SyncedMissed...)
+	return l
+}
+
+// TotalOutstanding returns the number of makeup prayers still owed.
+func (l *QadaLedger) TotalOutstanding() int {
+	total := 0
+	for _, count := range l.Outstanding {
+		total += count
+	}
+	return total
+}
+
+// QadaProjection estimates when the outstanding prayers will be made up.
+type QadaProjection struct {
+	TotalOutstanding int    `json:"totalOutstanding"`
+	DailyGoal        int    `json:"dailyGoal"`
+	DaysRemaining    int    `json:"daysRemaining"`
+	CompletionDate   string `json:"completionDate"` // YYYY-MM-DD, empty when nothing is owed
+}
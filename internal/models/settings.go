@@ -1,6 +1,14 @@
 // Package models contains data model definitions for the AzanAlarm application.
 package models
 
+import (
+	"path/filepath"
+	"time"
+)
+
+// MaxForbiddenWindowMinutes is the longest a forbidden window may be set to.
+const MaxForbiddenWindowMinutes = 60
+
 // AppTheme represents the application theme options.
 type AppTheme string
 
@@ -20,6 +28,16 @@ type AppSettings struct {
 	EnableVibration     bool                    `json:"enableVibration"`
 	Theme               AppTheme                `json:"theme"`
 	Language            string                  `json:"language"`
+	Jumuah              JumuahSettings          `json:"jumuah"`
+	ForbiddenWindows    ForbiddenWindowSettings `json:"forbiddenWindows"`
+	Sync                SyncSettings            `json:"sync"`
+	API                 APISettings             `json:"api"`
+}
+
+// JumuahSettings configures Friday (Jumu'ah) mode.
+type JumuahSettings struct {
+	Enabled     bool   `json:"enabled"`     // Show Jumu'ah in place of Dhuhr on Fridays
+	KhutbahTime string `json:"khutbahTime"` // HH:MM; empty uses the default mosque, then Dhuhr
 }
 
 // DefaultSettings returns the default application settings.
@@ -33,5 +51,68 @@ func DefaultSettings() AppSettings {
 		EnableVibration:     true,
 		Theme:               ThemeSystem,
 		Language:            "en",
+		Jumuah: JumuahSettings{
+			Enabled: true,
+		},
+		ForbiddenWindows: ForbiddenWindowSettings{
+			SunriseMinutes: 15,
+			ZenithMinutes:  10,
+			SunsetMinutes:  15,
+		},
+		API: APISettings{
+			Address: DefaultAPIAddress,
+		},
+	}
+}
+
+// Validate checks that the methods and theme are known and that the
+// Jumu'ah and forbidden window settings are in range.
+func (s *AppSettings) Validate() error {
+	var errs ValidationErrors
+
+	known := false
+	for _, m := range AllCalculationMethods() {
+		if m == s.CalculationMethod {
+			known = true
+			break
+		}
+	}
+	if !known {
+		errs.Add("calculationMethod", "unknown calculation method %q", s.CalculationMethod)
 	}
+	if s.JuristicMethod != Shafii && s.JuristicMethod != Hanafi {
+		errs.Add("juristicMethod", "unknown juristic method %q", s.JuristicMethod)
+	}
+	switch s.Theme {
+	case ThemeLight, ThemeDark, ThemeSystem:
+	default:
+		errs.Add("theme", "unknown theme %q", s.Theme)
+	}
+
+	if s.Jumuah.KhutbahTime != "" {
+		if _, err := time.Parse("15:04", s.Jumuah.KhutbahTime); err != nil {
+			errs.Add("jumuah.khutbahTime", "khutbah time must be HH:MM, got %q", s.Jumuah.KhutbahTime)
+		}
+	}
+
+	windows := []struct {
+		field   string
+		minutes int
+	}{
+		{"forbiddenWindows.sunriseMinutes", s.ForbiddenWindows.SunriseMinutes},
+		{"forbiddenWindows.zenithMinutes", s.ForbiddenWindows.ZenithMinutes},
+		{"forbiddenWindows.sunsetMinutes", s.ForbiddenWindows.SunsetMinutes},
+	}
+	for _, w := range windows {
+		if w.minutes < 0 || w.minutes > MaxForbiddenWindowMinutes {
+			errs.Add(w.field, "must be between 0 and %d minutes, got %d", MaxForbiddenWindowMinutes, w.minutes)
+		}
+	}
+
+	if s.Sync.Directory != "" && !filepath.IsAbs(s.Sync.Directory) {
+		errs.Add("sync.directory", "sync folder must be an absolute path, got %q", s.Sync.Directory)
+	}
+	s.API.validate(&errs)
+
+	return errs.Err()
 }
@@ -0,0 +1,28 @@
+// Package models contains data model definitions for the AzanAlarm application.
+package models
+
+// StorageWarning reports that a data file could not be read and was
+// recovered from its backup, or could not be loaded at all.
+type StorageWarning struct {
+	Key         string `json:"key"`         // Storage key, e.g. "alarms", empty if storage is unavailable
+	Message     string `json:"message"`     // Why the primary file was rejected
+	RecoveredAt int64  `json:"recoveredAt"` // Unix timestamp in milliseconds
+	// Unreadable is set if the data could not be loaded at all. It is not
+	// saved over until it loads again or is discarded.
+	Unreadable bool `json:"unreadable"`
+}
+
+// StorageInfo describes where and how application data is stored.
+type StorageInfo struct {
+	Backend         string `json:"backend"`         // "json" or "sqlite"
+	DataDir         string `json:"dataDir"`         // Directory holding the data
+	DataDirSource   string `json:"dataDirSource"`   // "flag", "env", "portable" or "default"
+	SQLiteAvailable bool   `json:"sqliteAvailable"` // Whether this build can use SQLite
+}
+
+// StorageChange reports data that was changed outside the app and
+// reloaded, or that could not be reloaded.
+type StorageChange struct {
+	Key   string `json:"key"`             // Storage key, e.g. "alarms"
+	Error string `json:"error,omitempty"` // Why the change was rejected; the previous data is kept
+}
@@ -0,0 +1,61 @@
+// Package models contains data model definitions for the AzanAlarm application.
+package models
+
+import "encoding/json"
+
+// SyncFormat identifies the device files in a sync folder.
+const SyncFormat = "azanalarm-sync"
+
+// SyncVersion is the version of the device file layout.
+const SyncVersion = 1
+
+// SyncSettings configures sync through a shared folder.
+type SyncSettings struct {
+	Directory string `json:"directory"` // Absolute path of the shared folder; empty disables sync
+}
+
+// SyncRecord is one synced item, or the tombstone of a deleted one. Of all
+// records with the same ID, the one with the latest UpdatedAt wins.
+type SyncRecord struct {
+	ID        string          `json:"id"`                // Alarm sync ID, or "lat,lon" for locations
+	UpdatedAt int64           `json:"updatedAt"`         // Unix timestamp in milliseconds
+	Deleted   bool            `json:"deleted,omitempty"` // Set on tombstones, which carry no data
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// SyncFile is the file each device writes to the sync folder. Devices only
+// ever write their own file, so file sync tools never see conflicts.
+type SyncFile struct {
+	Format     string       `json:"format"` // Always SyncFormat
+	Version    int          `json:"version"`
+	DeviceID   string       `json:"deviceId"`
+	DeviceName string       `json:"deviceName"`
+	WrittenAt  int64        `json:"writtenAt"` // Unix timestamp in milliseconds
+	Alarms     []SyncRecord `json:"alarms"`
+	Locations  []SyncRecord `json:"locations"` // Saved locations
+}
+
+// SyncResult reports what a sync changed on this device.
+type SyncResult struct {
+	SyncedAt         string         `json:"syncedAt"` // ISO 8601 time string
+	Devices          int            `json:"devices"`  // Other devices found in the sync folder
+	AlarmsAdded      int            `json:"alarmsAdded"`
+	AlarmsUpdated    int            `json:"alarmsUpdated"`
+	AlarmsDeleted    int            `json:"alarmsDeleted"`
+	LocationsAdded   int            `json:"locationsAdded"`
+	LocationsDeleted int            `json:"locationsDeleted"`
+	Conflicts        []SyncConflict `json:"conflicts"` // Remote alarms that were not applied
+}
+
+// SyncConflict is an alarm from another device that cannot be used on this
+// one, such as an iqamah alarm, whose mosque is not synced.
+type SyncConflict struct {
+	SyncID string `json:"syncId"`
+	Label  string `json:"label"` // The alarm's label, or its anchor's name
+	Reason string `json:"reason"`
+}
+
+// Changed checks if the sync changed any local data.
+func (r SyncResult) Changed() bool {
+	return r.AlarmsAdded+r.AlarmsUpdated+r.AlarmsDeleted+r.LocationsAdded+r.LocationsDeleted > 0
+}
@@ -0,0 +1,189 @@
+// Package models contains data model definitions for the AzanAlarm application.
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// timetableLocationTolerance is how far, in degrees, a location may be from
+// a timetable's location and still use it (roughly 2 km).
+const timetableLocationTolerance = 0.02
+
+// TimetableMapping describes how to read a mosque timetable CSV file.
+// Columns are given either as a header name or a 1-based column number.
+type TimetableMapping struct {
+	DateColumn    string `json:"dateColumn"`
+	FajrColumn    string `json:"fajrColumn"`
+	DhuhrColumn   string `json:"dhuhrColumn"`
+	AsrColumn     string `json:"asrColumn"`
+	MaghribColumn string `json:"maghribColumn"`
+	IshaColumn    string `json:"ishaColumn"`
+	DateFormat    string `json:"dateFormat"` // Go layout, defaults to 2006-01-02
+	TimeFormat    string `json:"timeFormat"` // Go layout, defaults to 24-hour or AM/PM
+	Delimiter     string `json:"delimiter"`  // Defaults to a comma
+	HasHeader     bool   `json:"hasHeader"`
+}
+
+// DefaultTimetableMapping returns a mapping for a CSV with a header row
+// named after the prayers.
+func DefaultTimetableMapping() TimetableMapping {
+	return TimetableMapping{
+		DateColumn:    "date",
+		FajrColumn:    "fajr",
+		DhuhrColumn:   "dhuhr",
+		AsrColumn:     "asr",
+		MaghribColumn: "maghrib",
+		IshaColumn:    "isha",
+		DateFormat:    "2006-01-02",
+		Delimiter:     ",",
+		HasHeader:     true,
+	}
+}
+
+// PrayerColumn returns the column configured for a prayer.
+func (m *TimetableMapping) PrayerColumn(prayer Prayer) string {
+	switch prayer {
+	case Fajr:
+		return m.FajrColumn
+	case Dhuhr:
+		return m.DhuhrColumn
+	case Asr:
+		return m.AsrColumn
+	case Maghrib:
+		return m.MaghribColumn
+	case Isha:
+		return m.IshaColumn
+	default:
+		return ""
+	}
+}
+
+// TimetableEntry holds the official times for one date.
+// Times are HH:MM in local time; an empty time falls back to calculation.
+type TimetableEntry struct {
+	Date    string `json:"date"` // YYYY-MM-DD
+	Fajr    string `json:"fajr"`
+	Dhuhr   string `json:"dhuhr"`
+	Asr     string `json:"asr"`
+	Maghrib string `json:"maghrib"`
+	Isha    string `json:"isha"`
+}
+
+// GetTime returns the official time for a specific prayer.
+func (e *TimetableEntry) GetTime(prayer Prayer) string {
+	switch prayer {
+	case Fajr:
+		return e.Fajr
+	case Dhuhr:
+		return e.Dhuhr
+	case Asr:
+		return e.Asr
+	case Maghrib:
+		return e.Maghrib
+	case Isha:
+		return e.Isha
+	default:
+		return ""
+	}
+}
+
+// SetTime sets the official time for a specific prayer.
+func (e *TimetableEntry) SetTime(prayer Prayer, value string) {
+	switch prayer {
+	case Fajr:
+		e.Fajr = value
+	case Dhuhr:
+		e.Dhuhr = value
+	case Asr:
+		e.Asr = value
+	case Maghrib:
+		e.Maghrib = value
+	case Isha:
+		e.Isha = value
+	}
+}
+
+// Timetable is an imported official timetable for a location.
+type Timetable struct {
+	ID           int              `json:"id"`
+	Name         string           `json:"name"`
+	LocationName string           `json:"locationName"`
+	Latitude     float64          `json:"latitude"`
+	Longitude    float64          `json:"longitude"`
+	Entries      []TimetableEntry `json:"entries"`
+	ImportedAt   int64            `json:"importedAt"` // Unix timestamp in milliseconds
+}
+
+// Clone returns a copy of the timetable that shares no memory with it.
+func (t Timetable) Clone() Timetable {
+	if t.Entries != nil {
+		t.Entries = append([]TimetableEntry{}, t.Entries...)
+	}
+	return t
+}
+
+// Covers checks if the timetable applies to the given coordinates.
+func (t *Timetable) Covers(latitude, longitude float64) bool {
+	return math.Abs(t.Latitude-latitude) <= timetableLocationTolerance &&
+		math.Abs(t.Longitude-longitude) <= timetableLocationTolerance
+}
+
+// Validate checks that the timetable has a name, that its coordinates are
+// on the globe and that every entry has a date and HH:MM times.
+func (t *Timetable) Validate() error {
+	var errs ValidationErrors
+	if t.Name == "" {
+		errs.Add("name", "timetable name is required")
+	}
+	if math.IsNaN(t.Latitude) || t.Latitude < -90 || t.Latitude > 90 {
+		errs.Add("latitude", "latitude must be between -90 and 90, got %v", t.Latitude)
+	}
+	if math.IsNaN(t.Longitude) || t.Longitude < -180 || t.Longitude > 180 {
+		errs.Add("longitude", "longitude must be between -180 and 180, got %v", t.Longitude)
+	}
+	for i, e := range t.Entries {
+		field := fmt.Sprintf("entries[%d]", i)
+		if _, err := time.Parse("2006-01-02", e.Date); err != nil {
+			errs.Add(field+".date", "invalid date %q", e.Date)
+		}
+		for _, prayer := range AllPrayers() {
+			value := e.GetTime(prayer)
+			if value == "" {
+				continue
+			}
+			if _, err := time.Parse("15:04", value); err != nil {
+				errs.Add(field+"."+string(prayer), "invalid time %q", value)
+			}
+		}
+	}
+	return errs.Err()
+}
+
+// TimetableParseError reports a problem on one line of an imported file.
+type TimetableParseError struct {
+	Line    int    `json:"line"`
+	Column  string `json:"column"`
+	Message string `json:"message"`
+}
+
+// TimetableDiff compares an official time with the calculated one.
+type TimetableDiff struct {
+	Date         string `json:"date"` // YYYY-MM-DD
+	Prayer       Prayer `json:"prayer"`
+	Official     string `json:"official"`     // HH:MM
+	Calculated   string `json:"calculated"`   // HH:MM
+	DeltaMinutes int    `json:"deltaMinutes"` // Official minus calculated
+	Suspicious   bool   `json:"suspicious"`   // Large enough to likely be a typo
+}
+
+// TimetableImportResult summarises a timetable import or preview.
+type TimetableImportResult struct {
+	TimetableID int                   `json:"timetableId"` // 0 for previews and failed imports
+	Imported    int                   `json:"imported"`    // Number of dates read
+	From        string                `json:"from"`        // First date, YYYY-MM-DD
+	To          string                `json:"to"`          // Last date, YYYY-MM-DD
+	Errors      []TimetableParseError `json:"errors"`
+	Diffs       []TimetableDiff       `json:"diffs"`
+}
@@ -0,0 +1,333 @@
+// Package services contains business logic for the AzanAlarm application.
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"AzanAlarm/internal/models"
+)
+
+// maxAlarmEvents caps the persisted alarm history; oldest entries are dropped first.
+const maxAlarmEvents = 5000
+
+// maxMissedLookback bounds how far back missed-alarm detection reaches,
+// e.g. after the laptop has been closed over a long holiday.
+const maxMissedLookback = 7 * 24 * time.Hour
+
+// alarmCheckState records how far missed-alarm detection has progressed.
+type alarmCheckState struct {
+	LastCheckedAt int64 `json:"lastCheckedAt"` // Unix timestamp in milliseconds
+}
+
+// AlarmEventService keeps a persisted log of alarm activity. It is safe for
+// concurrent use.
+type AlarmEventService struct {
+	storage *StorageService
+	mu      sync.RWMutex
+	events  []models.AlarmEvent
+	nextID  int
+	state   alarmCheckState
+}
+
+// NewAlarmEventService creates a new AlarmEventService instance.
+func NewAlarmEventService(storage *StorageService) *AlarmEventService {
+	es := &AlarmEventService{
+		storage: storage,
+		events:  []models.AlarmEvent{},
+		nextID:  1,
+	}
+
+	// Load existing history
+	var savedEvents []models.AlarmEvent
+	if err := storage.Load("alarm_events", &savedEvents); err == nil {
+		es.events = savedEvents
+		for _, e := range es.events {
+			if e.ID >= es.nextID {
+				es.nextID = e.ID + 1
+			}
+		}
+	}
+	_ = storage.Load("alarm_check", &es.state)
+
+	return es
+}
+
+// Reload replaces the alarm history with the one in storage, e.g. after
+// the file was replaced outside the app.
+func (es *AlarmEventService) Reload() error {
+	var events []models.AlarmEvent
+	if err := es.storage.Load("alarm_events", &events); err != nil {
+		return err
+	}
+
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	es.events = events
+	for _, e := range es.events {
+		if e.ID >= es.nextID {
+			es.nextID = e.ID + 1
+		}
+	}
+	return nil
+}
+
+// ReloadCheckState replaces the missed-alarm check marker with the one in
+// storage.
+func (es *AlarmEventService) ReloadCheckState() error {
+	var state alarmCheckState
+	if err := es.storage.Load("alarm_check", &state); err != nil {
+		return err
+	}
+
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	es.state = state
+	return nil
+}
+
+// RecordEvent appends an event to the alarm history.
+func (es *AlarmEventService) RecordEvent(event models.AlarmEvent) (models.AlarmEvent, error) {
+	if !event.Type.IsValid() {
+		return models.AlarmEvent{}, models.ValidationError("unknown alarm event type %q", event.Type)
+	}
+	if _, err := time.Parse(time.RFC3339, event.ScheduledAt); err != nil {
+		return models.AlarmEvent{}, models.ValidationError("invalid scheduled time %q", event.ScheduledAt)
+	}
+
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	event.ID = es.nextID
+	es.nextID++
+	if event.RecordedAt == 0 {
+		event.RecordedAt = time.Now().UnixMilli()
+	}
+
+	es.events = append(es.events, event)
+	if len(es.events) > maxAlarmEvents {
+		es.events = es.events[len(es.events)-maxAlarmEvents:]
+	}
+	if err := es.save(); err != nil {
+		return models.AlarmEvent{}, err
+	}
+	return event, nil
+}
+
+// stageImport computes the alarm history after restoring a backup,
+// without changing anything until the returned step is committed. Events
+// are pointed at the restored alarms through alarmIDs. In merge mode,
+// events already recorded for the same alarm, instant and type are
+// skipped. Imported events get new IDs.
+func (es *AlarmEventService) stageImport(events []models.AlarmEvent, alarmIDs map[int]int, mode models.RestoreMode) (restoreStep, error) {
+	counts := models.RestoreCounts{Section: "alarmEvents"}
+	for _, event := range events {
+		if !event.Type.IsValid() {
+			return restoreStep{}, models.ValidationError("unknown alarm event type %q", event.Type)
+		}
+		if _, err := time.Parse(time.RFC3339, event.ScheduledAt); err != nil {
+			return restoreStep{}, models.ValidationError("invalid scheduled time %q", event.ScheduledAt)
+		}
+	}
+
+	// The restored history is built in a copy of the service
+	es.mu.RLock()
+	staged := &AlarmEventService{nextID: es.nextID}
+	if mode == models.RestoreReplace {
+		counts.Removed = len(es.events)
+		staged.events = make([]models.AlarmEvent, 0, len(events))
+	} else {
+		staged.events = append(make([]models.AlarmEvent, 0, len(es.events)+len(events)), es.events...)
+	}
+	es.mu.RUnlock()
+
+	for _, event := range events {
+		if id, ok := alarmIDs[event.AlarmID]; ok {
+			event.AlarmID = id
+		}
+		if mode == models.RestoreMerge && staged.hasEvent(event) {
+			counts.Unchanged++
+			continue
+		}
+		event.ID = staged.nextID
+		staged.nextID++
+		staged.events = append(staged.events, event)
+		counts.Added++
+	}
+	sort.SliceStable(staged.events, func(i, j int) bool {
+		return staged.events[i].RecordedAt < staged.events[j].RecordedAt
+	})
+	if len(staged.events) > maxAlarmEvents {
+		staged.events = staged.events[len(staged.events)-maxAlarmEvents:]
+	}
+
+	var previous []models.AlarmEvent
+	var previousNextID int
+	return restoreStep{
+		counts: counts,
+		commit: func() error {
+			es.mu.Lock()
+			defer es.mu.Unlock()
+
+			previous, previousNextID = es.events, es.nextID
+			es.events, es.nextID = staged.events, staged.nextID
+			if err := es.save(); err != nil {
+				es.events, es.nextID = previous, previousNextID
+				return err
+			}
+			return nil
+		},
+		undo: func() error {
+			es.mu.Lock()
+			defer es.mu.Unlock()
+
+			es.events, es.nextID = previous, previousNextID
+			return es.save()
+		},
+	}, nil
+}
+
+// GetEvents returns the events matching the filter, oldest first.
+func (es *AlarmEventService) GetEvents(filter models.AlarmEventFilter) []models.AlarmEvent {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+
+	result := make([]models.AlarmEvent, 0)
+	for _, e := range es.events {
+		if filter.Matches(e) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// LastCheckedAt returns the instant up to which missed alarms have been detected.
+// The zero time is returned if detection has never run.
+func (es *AlarmEventService) LastCheckedAt() time.Time {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+
+	return es.lastCheckedAt()
+}
+
+// lastCheckedAt returns the check marker. The caller must hold the lock.
+func (es *AlarmEventService) lastCheckedAt() time.Time {
+	if es.state.LastCheckedAt == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(es.state.LastCheckedAt)
+}
+
+// MissedCheckWindow returns the range of instants that still need to be
+// checked for missed alarms, up to the given time.
+// The second return value is false if there is nothing to check.
+func (es *AlarmEventService) MissedCheckWindow(until time.Time) (time.Time, bool) {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+
+	from := es.lastCheckedAt()
+	if from.IsZero() {
+		// First run: nothing could have been missed yet
+		return time.Time{}, false
+	}
+	if earliest := until.Add(-maxMissedLookback); from.Before(earliest) {
+		from = earliest
+	}
+	return from, from.Before(until)
+}
+
+// RecordMissed records a missed event for every occurrence that has no
+// recorded activity, then advances the check marker to until.
+// It returns the newly recorded missed events.
+func (es *AlarmEventService) RecordMissed(occurrences []models.AlarmOccurrence, until time.Time) ([]models.AlarmEvent, error) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	missed := make([]models.AlarmEvent, 0)
+	now := time.Now().UnixMilli()
+
+	for _, o := range occurrences {
+		if es.hasActivity(o.AlarmID, o.Time) {
+			continue
+		}
+		event := models.AlarmEvent{
+			ID:          es.nextID,
+			AlarmID:     o.AlarmID,
+			Prayer:      o.Prayer,
+			Label:       o.Label,
+			Type:        models.AlarmMissed,
+			ScheduledAt: o.Time,
+			RecordedAt:  now,
+		}
+		es.nextID++
+		es.events = append(es.events, event)
+		missed = append(missed, event)
+	}
+
+	if len(es.events) > maxAlarmEvents {
+		es.events = es.events[len(es.events)-maxAlarmEvents:]
+	}
+	if len(missed) > 0 {
+		if err := es.save(); err != nil {
+			return nil, err
+		}
+	}
+
+	es.state.LastCheckedAt = until.UnixMilli()
+	if err := es.storage.Save("alarm_check", es.state); err != nil {
+		return nil, err
+	}
+	return missed, nil
+}
+
+// MarkChecked advances the check marker without recording anything.
+// It is used on first run so earlier occurrences are not reported.
+func (es *AlarmEventService) MarkChecked(until time.Time) error {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	es.state.LastCheckedAt = until.UnixMilli()
+	return es.storage.Save("alarm_check", es.state)
+}
+
+// hasActivity checks if any event exists for the alarm at the scheduled
+// instant. The caller must hold the lock.
+func (es *AlarmEventService) hasActivity(alarmID int, scheduledAt string) bool {
+	scheduled, err := time.Parse(time.RFC3339, scheduledAt)
+	if err != nil {
+		return false
+	}
+	for _, e := range es.events {
+		if e.AlarmID != alarmID {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, e.ScheduledAt)
+		if err != nil {
+			continue
+		}
+		// Compare at minute resolution, alarms never fire more precisely
+		if t.Truncate(time.Minute).Equal(scheduled.Truncate(time.Minute)) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasEvent checks if an event of the same type was recorded for the alarm
+// at the same scheduled instant. The caller must hold the lock.
+func (es *AlarmEventService) hasEvent(event models.AlarmEvent) bool {
+	for _, e := range es.events {
+		if e.AlarmID == event.AlarmID && e.Type == event.Type && e.ScheduledAt == event.ScheduledAt {
+			return true
+		}
+	}
+	return false
+}
+
+// save persists the alarm history to storage. The caller must hold the lock.
+func (es *AlarmEventService) save() error {
+	return es.storage.Save("alarm_events", es.events)
+}
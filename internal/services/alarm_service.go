@@ -2,16 +2,18 @@
 package services
 
 import (
+	"sync"
 	"time"
 
 	"AzanAlarm/internal/models"
 )
 
-// AlarmService handles alarm management.
+// AlarmService handles alarm management. It is safe for concurrent use.
 type AlarmService struct {
-	storage  *StorageService
-	alarms   []models.Alarm
-	nextID   int
+	storage *StorageService
+	mu      sync.RWMutex
+	alarms  []models.Alarm
+	nextID  int
 }
 
 // NewAlarmService creates a new AlarmService instance.
@@ -37,50 +39,154 @@ func NewAlarmService(storage *StorageService) *AlarmService {
 	return as
 }
 
-// GetAlarms returns all alarms.
+// Reload replaces the alarms with those in storage, e.g. after the file was
+// replaced outside the app. If any alarm is invalid, all are rejected and
+// the current ones are kept.
+func (as *AlarmService) Reload() error {
+	var alarms []models.Alarm
+	if err := as.storage.Load("alarms", &alarms); err != nil {
+		return err
+	}
+	for i := range alarms {
+		alarms[i].NormalizeAnchor()
+		if err := alarms[i].Validate(); err != nil {
+			return models.ValidationError("alarm %d: %v", alarms[i].ID, err)
+		}
+	}
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	as.alarms = alarms
+	// Never hand out an ID again, events may still refer to it
+	for _, a := range as.alarms {
+		if a.ID >= as.nextID {
+			as.nextID = a.ID + 1
+		}
+	}
+	return nil
+}
+
+// GetAlarms returns a copy of all alarms.
 func (as *AlarmService) GetAlarms() []models.Alarm {
-	return as.alarms
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+
+	result := make([]models.Alarm, len(as.alarms))
+	for i, a := range as.alarms {
+		result[i] = a.Clone()
+	}
+	return result
 }
 
-// GetAlarm returns a specific alarm by ID.
+// GetAlarm returns a copy of a specific alarm by ID.
 func (as *AlarmService) GetAlarm(id int) *models.Alarm {
-	for i := range as.alarms {
-		if as.alarms[i].ID == id {
-			return &as.alarms[i]
-		}
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+
+	if i := as.indexOf(id); i >= 0 {
+		alarm := as.alarms[i].Clone()
+		return &alarm
 	}
 	return nil
 }
 
 // CreateAlarm creates a new alarm.
 func (as *AlarmService) CreateAlarm(alarm models.Alarm) (models.Alarm, error) {
+	alarm.NormalizeAnchor()
+	if err := alarm.Validate(); err != nil {
+		return models.Alarm{}, err
+	}
+	alarm = alarm.Clone()
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
 	alarm.ID = as.nextID
 	as.nextID++
 	now := time.Now().UnixMilli()
 	alarm.CreatedAt = now
 	alarm.UpdatedAt = now
+	if alarm.SyncID == "" || as.indexOfSyncID(alarm.SyncID) >= 0 {
+		alarm.SyncID = newSyncID()
+	}
 
 	as.alarms = append(as.alarms, alarm)
 	if err := as.save(); err != nil {
 		return models.Alarm{}, err
 	}
-	return alarm, nil
+	return alarm.Clone(), nil
+}
+
+// CreateJumuahReminders creates the preset pre-Jumu'ah reminders in a
+// single save, skipping presets that already exist as a Jumu'ah alarm with
+// the same offset. It returns the alarms it created.
+func (as *AlarmService) CreateJumuahReminders() ([]models.Alarm, error) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	created := make([]models.Alarm, 0, 2)
+	updated := append(make([]models.Alarm, 0, len(as.alarms)+2), as.alarms...)
+	nextID := as.nextID
+	now := time.Now().UnixMilli()
+	for _, reminder := range models.NewJumuahReminders() {
+		if as.hasJumuahReminder(reminder.OffsetMinutes) {
+			continue
+		}
+		reminder.NormalizeAnchor()
+		if err := reminder.Validate(); err != nil {
+			return nil, err
+		}
+		reminder.ID = nextID
+		nextID++
+		reminder.CreatedAt = now
+		reminder.UpdatedAt = now
+		reminder.SyncID = newSyncID()
+		updated = append(updated, reminder)
+		created = append(created, reminder.Clone())
+	}
+	if len(created) == 0 {
+		return created, nil
+	}
+
+	previous, previousNextID := as.alarms, as.nextID
+	as.alarms, as.nextID = updated, nextID
+	if err := as.save(); err != nil {
+		as.alarms, as.nextID = previous, previousNextID
+		return nil, err
+	}
+	return created, nil
 }
 
 // UpdateAlarm updates an existing alarm.
 func (as *AlarmService) UpdateAlarm(alarm models.Alarm) error {
-	for i := range as.alarms {
-		if as.alarms[i].ID == alarm.ID {
-			alarm.UpdatedAt = time.Now().UnixMilli()
-			as.alarms[i] = alarm
-			return as.save()
-		}
+	alarm.NormalizeAnchor()
+	if err := alarm.Validate(); err != nil {
+		return err
 	}
-	return nil
+	alarm = alarm.Clone()
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	i := as.indexOf(alarm.ID)
+	if i < 0 {
+		return models.NotFoundError("alarm %d not found", alarm.ID)
+	}
+	alarm.UpdatedAt = time.Now().UnixMilli()
+	alarm.SyncID = as.alarms[i].SyncID
+	as.alarms[i] = alarm
+	return as.save()
 }
 
 // DeleteAlarm removes an alarm.
 func (as *AlarmService) DeleteAlarm(id int) error {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	if as.indexOf(id) < 0 {
+		return models.NotFoundError("alarm %d not found", id)
+	}
 	newAlarms := make([]models.Alarm, 0, len(as.alarms))
 	for _, a := range as.alarms {
 		if a.ID != id {
@@ -93,22 +199,224 @@ func (as *AlarmService) DeleteAlarm(id int) error {
 
 // ToggleAlarm toggles the active state of an alarm.
 func (as *AlarmService) ToggleAlarm(id int, active bool) error {
-	for i := range as.alarms {
-		if as.alarms[i].ID == id {
-			as.alarms[i].IsActive = active
-			as.alarms[i].UpdatedAt = time.Now().UnixMilli()
-			return as.save()
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	i := as.indexOf(id)
+	if i < 0 {
+		return models.NotFoundError("alarm %d not found", id)
+	}
+	// Not an edit: UpdatedAt is the sync version, and activation is not synced
+	as.alarms[i].IsActive = active
+	return as.save()
+}
+
+// SetActiveStates sets the active state of several alarms in one save.
+// Either all changes are persisted or none are. Unknown IDs are ignored.
+func (as *AlarmService) SetActiveStates(states map[int]bool) error {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	updated := make([]models.Alarm, len(as.alarms))
+	copy(updated, as.alarms)
+
+	changed := false
+	for i := range updated {
+		active, ok := states[updated[i].ID]
+		if !ok || updated[i].IsActive == active {
+			continue
 		}
+		updated[i].IsActive = active
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+
+	previous := as.alarms
+	as.alarms = updated
+	if err := as.save(); err != nil {
+		as.alarms = previous
+		return err
 	}
 	return nil
 }
 
+// stageImport computes the alarms after restoring a backup, without
+// changing anything until the returned step is committed. Iqamah anchors
+// are pointed at the restored mosques through mosqueIDs. In merge mode,
+// alarms equal to an existing one are skipped and the rest get new IDs. It
+// also returns the ID each imported alarm ends up with.
+func (as *AlarmService) stageImport(alarms []models.Alarm, mosqueIDs map[int]int, mode models.RestoreMode) (restoreStep, map[int]int, error) {
+	counts := models.RestoreCounts{Section: "alarms"}
+	ids := make(map[int]int, len(alarms))
+
+	imported := make([]models.Alarm, 0, len(alarms))
+	for _, alarm := range alarms {
+		alarm.NormalizeAnchor()
+		if id, ok := mosqueIDs[alarm.Anchor.MosqueID]; ok && alarm.Anchor.MosqueID != 0 {
+			alarm.Anchor.MosqueID = id
+		}
+		if err := alarm.Validate(); err != nil {
+			return restoreStep{}, nil, err
+		}
+		imported = append(imported, alarm.Clone())
+	}
+
+	// The restored alarms are built in a copy of the service
+	as.mu.RLock()
+	staged := &AlarmService{nextID: as.nextID}
+	if mode == models.RestoreReplace {
+		counts.Removed = len(as.alarms)
+		staged.alarms = make([]models.Alarm, 0, len(imported))
+		staged.nextID = 1
+		for _, alarm := range imported {
+			if alarm.ID >= staged.nextID {
+				staged.nextID = alarm.ID + 1
+			}
+		}
+	} else {
+		staged.alarms = append(make([]models.Alarm, 0, len(as.alarms)+len(imported)), as.alarms...)
+	}
+	as.mu.RUnlock()
+
+	for _, alarm := range imported {
+		if mode == models.RestoreMerge {
+			if existing := staged.findEqual(alarm); existing != nil {
+				ids[alarm.ID] = existing.ID
+				counts.Unchanged++
+				continue
+			}
+		}
+		// Backup IDs are kept on replace, unless they clash
+		oldID := alarm.ID
+		if mode == models.RestoreMerge || alarm.ID <= 0 || staged.indexOf(alarm.ID) >= 0 {
+			alarm.ID = staged.nextID
+			staged.nextID++
+		}
+		ids[oldID] = alarm.ID
+		if alarm.SyncID == "" || staged.indexOfSyncID(alarm.SyncID) >= 0 {
+			alarm.SyncID = newSyncID()
+		}
+		staged.alarms = append(staged.alarms, alarm)
+		counts.Added++
+	}
+
+	var previous []models.Alarm
+	var previousNextID int
+	return restoreStep{
+		counts: counts,
+		commit: func() error {
+			as.mu.Lock()
+			defer as.mu.Unlock()
+
+			previous, previousNextID = as.alarms, as.nextID
+			as.alarms, as.nextID = staged.alarms, staged.nextID
+			if err := as.save(); err != nil {
+				as.alarms, as.nextID = previous, previousNextID
+				return err
+			}
+			return nil
+		},
+		undo: func() error {
+			as.mu.Lock()
+			defer as.mu.Unlock()
+
+			as.alarms, as.nextID = previous, previousNextID
+			return as.save()
+		},
+	}, ids, nil
+}
+
+// AssignSyncIDs gives alarms created before sync existed a sync ID.
+func (as *AlarmService) AssignSyncIDs() error {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	changed := false
+	for i := range as.alarms {
+		if as.alarms[i].SyncID == "" {
+			as.alarms[i].SyncID = newSyncID()
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return as.save()
+}
+
+// ApplySync applies the outcome of a sync: alarms are added or updated by
+// sync ID, keeping their timestamps, and deleted by sync ID. Whether an
+// alarm is on is not synced: updated alarms keep their local state and
+// added alarms are on. It returns the counts and the local IDs of deleted
+// alarms.
+func (as *AlarmService) ApplySync(upserts []models.Alarm, deletes []string) (models.SyncResult, []int, error) {
+	var result models.SyncResult
+	for i := range upserts {
+		upserts[i].NormalizeAnchor()
+		if err := upserts[i].Validate(); err != nil {
+			return result, nil, models.ValidationError("synced alarm %s: %v", upserts[i].SyncID, err)
+		}
+	}
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	updated := make([]models.Alarm, 0, len(as.alarms)+len(upserts))
+	for _, a := range as.alarms {
+		updated = append(updated, a.Clone())
+	}
+	previous, previousNextID := as.alarms, as.nextID
+	as.alarms = updated
+
+	for _, alarm := range upserts {
+		alarm = alarm.Clone()
+		if i := as.indexOfSyncID(alarm.SyncID); i >= 0 {
+			alarm.ID = as.alarms[i].ID
+			alarm.IsActive = as.alarms[i].IsActive
+			as.alarms[i] = alarm
+			result.AlarmsUpdated++
+			continue
+		}
+		alarm.ID = as.nextID
+		as.nextID++
+		alarm.IsActive = true
+		as.alarms = append(as.alarms, alarm)
+		result.AlarmsAdded++
+	}
+
+	deletedIDs := make([]int, 0, len(deletes))
+	for _, syncID := range deletes {
+		i := as.indexOfSyncID(syncID)
+		if i < 0 {
+			continue
+		}
+		deletedIDs = append(deletedIDs, as.alarms[i].ID)
+		as.alarms = append(as.alarms[:i], as.alarms[i+1:]...)
+		result.AlarmsDeleted++
+	}
+
+	if !result.Changed() {
+		as.alarms, as.nextID = previous, previousNextID
+		return result, nil, nil
+	}
+	if err := as.save(); err != nil {
+		as.alarms, as.nextID = previous, previousNextID
+		return models.SyncResult{}, nil, err
+	}
+	return result, deletedIDs, nil
+}
+
 // GetActiveAlarms returns only active alarms.
 func (as *AlarmService) GetActiveAlarms() []models.Alarm {
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+
 	active := make([]models.Alarm, 0)
 	for _, a := range as.alarms {
 		if a.IsActive {
-			active = append(active, a)
+			active = append(active, a.Clone())
 		}
 	}
 	return active
@@ -116,16 +424,85 @@ func (as *AlarmService) GetActiveAlarms() []models.Alarm {
 
 // GetAlarmsForPrayer returns alarms for a specific prayer.
 func (as *AlarmService) GetAlarmsForPrayer(prayer models.Prayer) []models.Alarm {
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+
 	result := make([]models.Alarm, 0)
 	for _, a := range as.alarms {
-		if a.Prayer == prayer && a.IsActive {
-			result = append(result, a)
+		anchor := a.EffectiveAnchor()
+		if anchor.Type == models.AnchorPrayer && anchor.Prayer == prayer && a.IsActive {
+			result = append(result, a.Clone())
 		}
 	}
 	return result
 }
 
-// save persists alarms to storage.
+// indexOf returns the position of an alarm in the list, or -1. The caller
+// must hold the lock.
+func (as *AlarmService) indexOf(id int) int {
+	for i := range as.alarms {
+		if as.alarms[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexOfSyncID returns the position of the alarm with the sync ID, or -1.
+// The caller must hold the lock.
+func (as *AlarmService) indexOfSyncID(syncID string) int {
+	for i := range as.alarms {
+		if as.alarms[i].SyncID == syncID {
+			return i
+		}
+	}
+	return -1
+}
+
+// hasJumuahReminder checks if a Jumu'ah alarm with the offset exists. The
+// caller must hold the lock.
+func (as *AlarmService) hasJumuahReminder(offsetMinutes int) bool {
+	for i := range as.alarms {
+		if as.alarms[i].EffectiveAnchor().Type == models.AnchorJumuah && as.alarms[i].OffsetMinutes == offsetMinutes {
+			return true
+		}
+	}
+	return false
+}
+
+// findEqual returns the stored alarm that rings at the same time with the
+// same label as the given one, or nil. The caller must hold the lock.
+func (as *AlarmService) findEqual(alarm models.Alarm) *models.Alarm {
+	for i := range as.alarms {
+		a := &as.alarms[i]
+		if a.EffectiveAnchor() == alarm.EffectiveAnchor() &&
+			a.OffsetMinutes == alarm.OffsetMinutes &&
+			a.Label == alarm.Label &&
+			sameDays(a.RepeatDays, alarm.RepeatDays) {
+			return a
+		}
+	}
+	return nil
+}
+
+// sameDays checks if two repeat-day lists contain the same days.
+func sameDays(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[int]bool, len(a))
+	for _, d := range a {
+		seen[d] = true
+	}
+	for _, d := range b {
+		if !seen[d] {
+			return false
+		}
+	}
+	return true
+}
+
+// save persists alarms to storage. The caller must hold the lock.
 func (as *AlarmService) save() error {
 	return as.storage.Save("alarms", as.alarms)
 }
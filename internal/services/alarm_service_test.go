@@ -0,0 +1,158 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"AzanAlarm/internal/models"
+)
+
+// newTestAlarmService creates an AlarmService on an in-memory backend.
+func newTestAlarmService(t *testing.T) (*AlarmService, *StorageService) {
+	t.Helper()
+	storage := NewStorageServiceWithBackend(NewMemoryBackend(), "")
+	return NewAlarmService(storage), storage
+}
+
+// TestAlarmServiceConcurrentChanges creates, updates, toggles and deletes
+// alarms from many goroutines. Run it with -race to catch unguarded access.
+func TestAlarmServiceConcurrentChanges(t *testing.T) {
+	as, storage := newTestAlarmService(t)
+
+	const workers = 8
+	const perWorker = 25
+
+	var wg sync.WaitGroup
+	created := make(chan models.Alarm, workers*perWorker)
+	deleted := make(chan int, workers*perWorker)
+	errs := make(chan error, workers*perWorker*4)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				alarm, err := as.CreateAlarm(models.NewAlarm(models.Fajr, i%30))
+				if err != nil {
+					errs <- err
+					continue
+				}
+				created <- alarm
+
+				alarm.Label = "updated"
+				alarm.OffsetMinutes = -i
+				if err := as.UpdateAlarm(alarm); err != nil {
+					errs <- err
+				}
+				if err := as.ToggleAlarm(alarm.ID, i%2 == 0); err != nil {
+					errs <- err
+				}
+				as.GetAlarms()
+				if i%3 == 0 {
+					if err := as.DeleteAlarm(alarm.ID); err != nil {
+						errs <- err
+					} else {
+						deleted <- alarm.ID
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(created)
+	close(deleted)
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	ids := make(map[int]bool)
+	syncIDs := make(map[string]bool)
+	for alarm := range created {
+		if ids[alarm.ID] {
+			t.Errorf("alarm ID %d was handed out twice", alarm.ID)
+		}
+		ids[alarm.ID] = true
+		if syncIDs[alarm.SyncID] {
+			t.Errorf("sync ID %q was handed out twice", alarm.SyncID)
+		}
+		syncIDs[alarm.SyncID] = true
+	}
+	for id := range deleted {
+		delete(ids, id)
+	}
+
+	alarms := as.GetAlarms()
+	if len(alarms) != len(ids) {
+		t.Fatalf("got %d alarms, want %d", len(alarms), len(ids))
+	}
+	for _, alarm := range alarms {
+		if !ids[alarm.ID] {
+			t.Errorf("alarm %d should have been deleted", alarm.ID)
+		}
+		if alarm.Label != "updated" {
+			t.Errorf("alarm %d lost its update, label %q", alarm.ID, alarm.Label)
+		}
+	}
+
+	// What was saved last must match what is in memory
+	var saved []models.Alarm
+	if err := storage.Load("alarms", &saved); err != nil {
+		t.Fatalf("loading saved alarms: %v", err)
+	}
+	if len(saved) != len(alarms) {
+		t.Errorf("storage has %d alarms, service has %d", len(saved), len(alarms))
+	}
+}
+
+func TestAlarmServiceNotFound(t *testing.T) {
+	as, _ := newTestAlarmService(t)
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"update", func() error {
+			alarm := models.NewAlarm(models.Dhuhr, 0)
+			alarm.ID = 42
+			return as.UpdateAlarm(alarm)
+		}},
+		{"delete", func() error { return as.DeleteAlarm(42) }},
+		{"toggle", func() error { return as.ToggleAlarm(42, true) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, models.ErrNotFound) {
+				t.Errorf("got %v, want a not-found error", err)
+			}
+		})
+	}
+}
+
+func TestCreateJumuahRemindersOnce(t *testing.T) {
+	as, _ := newTestAlarmService(t)
+
+	first, err := as.CreateJumuahReminders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := len(models.NewJumuahReminders()); len(first) != want {
+		t.Fatalf("created %d reminders, want %d", len(first), want)
+	}
+
+	// A deleted preset is created again, the other one is not
+	if err := as.DeleteAlarm(first[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	second, err := as.CreateJumuahReminders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second) != 1 || second[0].OffsetMinutes != first[0].OffsetMinutes {
+		t.Errorf("got %+v, want only the deleted reminder again", second)
+	}
+	if got := len(as.GetAlarms()); got != len(first) {
+		t.Errorf("got %d alarms, want %d", got, len(first))
+	}
+}
@@ -0,0 +1,472 @@
+// Package services contains business logic for the AzanAlarm application.
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"AzanAlarm/internal/models"
+)
+
+// apiMaxBodySize limits the size of request bodies.
+const apiMaxBodySize = 1 << 20
+
+// apiShutdownTimeout is how long requests in flight may take to finish when
+// the server is stopped.
+const apiShutdownTimeout = 5 * time.Second
+
+// APIServer serves prayer times, alarms, locations and settings as JSON over
+// HTTP, so scripts and dashboards on the same machine or network can use
+// them. Every request must carry the token from the API settings as a
+// bearer token. It is safe for concurrent use.
+type APIServer struct {
+	settings  *SettingsService
+	schedule  *ScheduleService
+	alarms    *AlarmService
+	profiles  *ProfileService
+	locations *LocationService
+	handler   http.Handler
+
+	mu       sync.Mutex
+	server   *http.Server
+	config   string           // Configured address of the running server
+	address  string           // Address the server listens on, empty when stopped
+	onChange func(key string) // Called with the storage key of data changed through the API
+	onError  func(err error)  // Called with errors that cannot be returned to a caller
+}
+
+// NewAPIServer creates a new APIServer instance. It does not listen until
+// Apply is called.
+func NewAPIServer(
+	settings *SettingsService,
+	schedule *ScheduleService,
+	alarms *AlarmService,
+	profiles *ProfileService,
+	locations *LocationService,
+) *APIServer {
+	s := &APIServer{
+		settings:  settings,
+		schedule:  schedule,
+		alarms:    alarms,
+		profiles:  profiles,
+		locations: locations,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/prayer-times", s.getPrayerTimes)
+	mux.HandleFunc("GET /api/v1/next-prayer", s.getNextPrayer)
+	mux.HandleFunc("GET /api/v1/alarms", s.getAlarms)
+	mux.HandleFunc("POST /api/v1/alarms", s.createAlarm)
+	mux.HandleFunc("GET /api/v1/alarms/{id}", s.getAlarm)
+	mux.HandleFunc("PUT /api/v1/alarms/{id}", s.updateAlarm)
+	mux.HandleFunc("DELETE /api/v1/alarms/{id}", s.deleteAlarm)
+	mux.HandleFunc("GET /api/v1/location", s.getCurrentLocation)
+	mux.HandleFunc("PUT /api/v1/location", s.setCurrentLocation)
+	mux.HandleFunc("GET /api/v1/locations", s.getSavedLocations)
+	mux.HandleFunc("POST /api/v1/locations", s.saveLocation)
+	mux.HandleFunc("DELETE /api/v1/locations/{id}", s.deleteLocation)
+	mux.HandleFunc("GET /api/v1/settings", s.getSettings)
+	mux.HandleFunc("PUT /api/v1/settings", s.saveSettings)
+	s.handler = s.authenticate(mux)
+
+	return s
+}
+
+// OnChange registers a function called with the storage key of data
+// changed through the API, e.g. to refresh a user interface.
+func (s *APIServer) OnChange(notify func(key string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.onChange = notify
+}
+
+// OnError registers a function called with errors that happen while
+// serving, outside of any call, so they reach the application's log.
+func (s *APIServer) OnError(report func(err error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.onError = report
+}
+
+// logError passes an error to the function registered with OnError.
+func (s *APIServer) logError(err error) {
+	s.mu.Lock()
+	report := s.onError
+	s.mu.Unlock()
+
+	if report != nil {
+		report(err)
+	}
+}
+
+// Handler returns the HTTP handler of the API, including authentication.
+func (s *APIServer) Handler() http.Handler {
+	return s.handler
+}
+
+// Address returns the address the server listens on, or "" if it is
+// stopped.
+func (s *APIServer) Address() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.address
+}
+
+// Apply starts, restarts or stops the server to match the API settings.
+// Token changes take effect without a restart.
+func (s *APIServer) Apply() error {
+	config := s.settings.GetSettings().API
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	address := ""
+	if config.Enabled {
+		address = config.ListenAddress()
+	}
+	if address == s.config {
+		return nil
+	}
+	s.stop()
+	if address == "" {
+		return nil
+	}
+	if config.Token == "" {
+		return models.NotConfiguredError("no API token set")
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %s: %w", address, err)
+	}
+	server := &http.Server{
+		Handler:           s.handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logError(fmt.Errorf("serving API: %w", err))
+		}
+	}()
+	s.server = server
+	s.config = address
+	s.address = listener.Addr().String()
+	return nil
+}
+
+// Stop stops the server, letting requests in flight finish.
+func (s *APIServer) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stop()
+}
+
+// stop stops the server if it is running. The caller must hold the lock.
+func (s *APIServer) stop() {
+	if s.server == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), apiShutdownTimeout)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		s.server.Close()
+	}
+	s.server = nil
+	s.config = ""
+	s.address = ""
+}
+
+// authenticate rejects requests without the API token.
+func (s *APIServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := s.settings.GetSettings().API.Token
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="AzanAlarm"`)
+			s.writeJSON(w, http.StatusUnauthorized, &models.AppError{
+				Code:    models.CodeUnauthorized,
+				Message: "missing or invalid API token",
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// changed reports data changed through the API.
+func (s *APIServer) changed(key string) {
+	s.mu.Lock()
+	notify := s.onChange
+	s.mu.Unlock()
+
+	if notify != nil {
+		notify(key)
+	}
+}
+
+// getPrayerTimes returns the prayer times of the date given as
+// ?date=YYYY-MM-DD, or of today.
+func (s *APIServer) getPrayerTimes(w http.ResponseWriter, r *http.Request) {
+	date := time.Now()
+	if param := r.URL.Query().Get("date"); param != "" {
+		parsed, err := time.ParseInLocation(dateLayout, param, time.Local)
+		if err != nil {
+			s.writeError(w, models.ValidationError("invalid date %q, expected YYYY-MM-DD", param))
+			return
+		}
+		date = parsed
+	}
+
+	times, ok := s.schedule.PrayerTimesFor(date)
+	if !ok {
+		s.writeError(w, models.NotConfiguredError("no location set"))
+		return
+	}
+	s.writeJSON(w, http.StatusOK, times)
+}
+
+// getNextPrayer returns the current prayer window and the next prayer.
+func (s *APIServer) getNextPrayer(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, http.StatusOK, s.schedule.NextPrayerAt(time.Now()))
+}
+
+// getAlarms returns all alarms.
+func (s *APIServer) getAlarms(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, http.StatusOK, s.alarms.GetAlarms())
+}
+
+// getAlarm returns one alarm.
+func (s *APIServer) getAlarm(w http.ResponseWriter, r *http.Request) {
+	alarm, err := s.alarmFromPath(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, alarm)
+}
+
+// createAlarm creates an alarm and returns it with its ID.
+func (s *APIServer) createAlarm(w http.ResponseWriter, r *http.Request) {
+	var alarm models.Alarm
+	if err := readJSON(r, &alarm); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	created, err := s.alarms.CreateAlarm(alarm)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.changed("alarms")
+	s.writeJSON(w, http.StatusCreated, created)
+}
+
+// updateAlarm updates an alarm. Fields missing from the body keep their
+// current values.
+func (s *APIServer) updateAlarm(w http.ResponseWriter, r *http.Request) {
+	alarm, err := s.alarmFromPath(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	id := alarm.ID
+	if err := readJSON(r, alarm); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	alarm.ID = id
+	if err := s.alarms.UpdateAlarm(*alarm); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.changed("alarms")
+	s.writeJSON(w, http.StatusOK, s.alarms.GetAlarm(id))
+}
+
+// deleteAlarm deletes an alarm and removes it from alarm profiles.
+func (s *APIServer) deleteAlarm(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	if err := s.alarms.DeleteAlarm(id); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	if err := s.profiles.RemoveAlarm(id); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.changed("alarms")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// alarmFromPath returns a copy of the alarm named by the {id} path value.
+func (s *APIServer) alarmFromPath(r *http.Request) (*models.Alarm, error) {
+	id, err := pathID(r)
+	if err != nil {
+		return nil, err
+	}
+	alarm := s.alarms.GetAlarm(id)
+	if alarm == nil {
+		return nil, models.NotFoundError("alarm %d not found", id)
+	}
+	return alarm, nil
+}
+
+// getCurrentLocation returns the current location, or null if none is set.
+func (s *APIServer) getCurrentLocation(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, http.StatusOK, s.locations.GetCurrentLocation())
+}
+
+// setCurrentLocation sets the current location.
+func (s *APIServer) setCurrentLocation(w http.ResponseWriter, r *http.Request) {
+	var location models.Location
+	if err := readJSON(r, &location); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	if err := s.locations.SetCurrentLocation(location); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.changed("current_location")
+	s.writeJSON(w, http.StatusOK, s.locations.GetCurrentLocation())
+}
+
+// getSavedLocations returns the saved locations.
+func (s *APIServer) getSavedLocations(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, http.StatusOK, s.locations.GetSavedLocations())
+}
+
+// saveLocation adds a location to the saved locations and returns them.
+func (s *APIServer) saveLocation(w http.ResponseWriter, r *http.Request) {
+	var location models.Location
+	if err := readJSON(r, &location); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	if err := s.locations.SaveLocation(location); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.changed("saved_locations")
+	s.writeJSON(w, http.StatusCreated, s.locations.GetSavedLocations())
+}
+
+// deleteLocation removes a saved location.
+func (s *APIServer) deleteLocation(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	if err := s.locations.DeleteLocation(id); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.changed("saved_locations")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getSettings returns the settings. The API token is left out.
+func (s *APIServer) getSettings(w http.ResponseWriter, r *http.Request) {
+	settings := s.settings.GetSettings()
+	settings.API.Token = ""
+	s.writeJSON(w, http.StatusOK, settings)
+}
+
+// saveSettings updates the settings. Fields missing from the body keep their
+// current values. The API settings themselves can only be changed in the
+// app, so a token cannot be used to widen its own access.
+func (s *APIServer) saveSettings(w http.ResponseWriter, r *http.Request) {
+	settings := s.settings.GetSettings()
+	api := settings.API
+	if err := readJSON(r, &settings); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	settings.API = api
+	if err := s.settings.SaveSettings(settings); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.changed("settings")
+	settings.API.Token = ""
+	s.writeJSON(w, http.StatusOK, settings)
+}
+
+// pathID parses the {id} path value.
+func pathID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return 0, models.ValidationError("invalid ID %q", r.PathValue("id"))
+	}
+	return id, nil
+}
+
+// readJSON decodes a request body into v.
+func readJSON(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(io.LimitReader(r.Body, apiMaxBodySize))
+	if err := decoder.Decode(v); err != nil {
+		return models.ValidationError("invalid JSON body: %v", err)
+	}
+	return nil
+}
+
+// writeError writes an error as an AppError with a matching status code.
+func (s *APIServer) writeError(w http.ResponseWriter, err error) {
+	appErr := models.AsAppError(err)
+	status := http.StatusInternalServerError
+	switch appErr.Code {
+	case models.CodeValidation:
+		status = http.StatusBadRequest
+	case models.CodeNotFound:
+		status = http.StatusNotFound
+	case models.CodeNotConfigured:
+		status = http.StatusConflict
+	case models.CodeUnauthorized:
+		status = http.StatusUnauthorized
+	case models.CodeStorageUnavailable:
+		status = http.StatusServiceUnavailable
+	case models.CodeNetwork:
+		status = http.StatusBadGateway
+	}
+	s.writeJSON(w, status, appErr)
+}
+
+// writeJSON writes v as the JSON response body.
+func (s *APIServer) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.logError(fmt.Errorf("writing API response: %w", err))
+	}
+}
+
+// newAPIToken returns a new random API token. Unlike sync IDs, a token
+// must not be guessable, so there is no fallback if crypto/rand fails.
+func newAPIToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("cannot generate API token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
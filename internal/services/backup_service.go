@@ -0,0 +1,423 @@
+// Package services contains business logic for the AzanAlarm application.
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"AzanAlarm/internal/models"
+)
+
+// backupSections maps the sections of BackupData to the storage keys whose
+// schema migrations apply to them.
+var backupSections = map[string]string{
+	"settings":        "settings",
+	"currentLocation": "current_location",
+	"savedLocations":  "saved_locations",
+	"mosques":         "mosques",
+	"alarms":          "alarms",
+	"alarmProfiles":   "alarm_profiles",
+	"timetables":      "timetables",
+	"prayerLog":       "prayer_log",
+	"alarmEvents":     "alarm_events",
+	"qada":            "qada",
+}
+
+// BackupService exports all user data to a single file and restores it.
+type BackupService struct {
+	storage     *StorageService
+	settings    *SettingsService
+	locations   *LocationService
+	mosques     *MosqueService
+	alarms      *AlarmService
+	profiles    *ProfileService
+	timetables  *TimetableService
+	prayerLog   *PrayerLogService
+	alarmEvents *AlarmEventService
+	qada        *QadaService
+}
+
+// NewBackupService creates a new BackupService instance.
+func NewBackupService(
+	storage *StorageService,
+	settings *SettingsService,
+	locations *LocationService,
+	mosques *MosqueService,
+	alarms *AlarmService,
+	profiles *ProfileService,
+	timetables *TimetableService,
+	prayerLog *PrayerLogService,
+	alarmEvents *AlarmEventService,
+	qada *QadaService,
+) *BackupService {
+	return &BackupService{
+		storage:     storage,
+		settings:    settings,
+		locations:   locations,
+		mosques:     mosques,
+		alarms:      alarms,
+		profiles:    profiles,
+		timetables:  timetables,
+		prayerLog:   prayerLog,
+		alarmEvents: alarmEvents,
+		qada:        qada,
+	}
+}
+
+// Export writes a backup of all user data to path.
+func (bs *BackupService) Export(path string) error {
+	settings := bs.settings.GetSettings()
+	ledger := bs.qada.GetLedger()
+	data := models.BackupData{
+		Settings:        &settings,
+		CurrentLocation: bs.locations.GetCurrentLocation(),
+		SavedLocations:  bs.locations.GetSavedLocations(),
+		Mosques:         bs.mosques.GetMosques(),
+		Alarms:          bs.alarms.GetAlarms(),
+		AlarmProfiles:   bs.profiles.GetProfiles(),
+		Timetables:      bs.timetables.GetTimetables(),
+		PrayerLog:       bs.prayerLog.GetEntries("", ""),
+		AlarmEvents:     bs.alarmEvents.GetEvents(models.AlarmEventFilter{}),
+		Qada:            &ledger,
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	checksum, err := backupChecksum(payload)
+	if err != nil {
+		return err
+	}
+	backup := models.Backup{
+		Format:        models.BackupFormat,
+		Version:       models.BackupVersion,
+		SchemaVersion: SchemaVersion,
+		CreatedAt:     time.Now().Format(time.RFC3339),
+		Checksum:      checksum,
+		Data:          payload,
+	}
+	encoded, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(path, encoded); err != nil {
+		return models.StorageError(err)
+	}
+	return nil
+}
+
+// restoreStep is one section of a backup, computed without changing
+// anything. Every section is staged before any is committed, so a backup
+// that cannot be restored leaves the data as it was.
+type restoreStep struct {
+	counts models.RestoreCounts
+	commit func() error // Saves the section
+	undo   func() error // Puts back what commit replaced
+}
+
+// unchangedStep is a section that is kept as it is.
+func unchangedStep(section string) restoreStep {
+	noop := func() error { return nil }
+	return restoreStep{
+		counts: models.RestoreCounts{Section: section, Unchanged: 1},
+		commit: noop,
+		undo:   noop,
+	}
+}
+
+// Import restores a backup from path. The whole backup is checked and
+// staged before anything is changed, and the sections already saved are
+// put back if saving a later one fails. In merge mode, settings, the
+// current location and the qada ledger are only restored if they were
+// never set on this machine.
+func (bs *BackupService) Import(path string, mode models.RestoreMode) (models.RestoreSummary, error) {
+	if !mode.IsValid() {
+		return models.RestoreSummary{}, models.ValidationError("unknown restore mode %q", mode)
+	}
+
+	backup, data, err := readBackup(path)
+	if err != nil {
+		return models.RestoreSummary{}, err
+	}
+	if err := bs.validate(data); err != nil {
+		return models.RestoreSummary{}, err
+	}
+
+	steps, err := bs.stage(data, mode)
+	if err != nil {
+		return models.RestoreSummary{}, err
+	}
+	for i, step := range steps {
+		if err := step.commit(); err != nil {
+			return models.RestoreSummary{}, undoRestore(steps[:i], err)
+		}
+	}
+
+	summary := models.RestoreSummary{
+		Mode:            mode,
+		BackupCreatedAt: backup.CreatedAt,
+		Sections:        make([]models.RestoreCounts, 0, len(steps)),
+	}
+	for _, step := range steps {
+		summary.Sections = append(summary.Sections, step.counts)
+	}
+
+	// Count missed prayers that came in with the log
+	if _, err := bs.qada.SyncFromPrayerLog(); err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
+
+// undoRestore puts back the sections already committed, last first, after
+// committing the next one failed with err.
+func undoRestore(committed []restoreStep, err error) error {
+	var failed []string
+	for i := len(committed) - 1; i >= 0; i-- {
+		if undoErr := committed[i].undo(); undoErr != nil {
+			failed = append(failed, committed[i].counts.Section)
+		}
+	}
+	if len(failed) > 0 {
+		return models.StorageError(fmt.Errorf("%w, and %s could not be put back",
+			err, strings.Join(failed, ", ")))
+	}
+	return err
+}
+
+// stage computes every section of a restore in the order they are
+// committed. IDs that change are passed on to the sections referring to
+// them.
+func (bs *BackupService) stage(data models.BackupData, mode models.RestoreMode) ([]restoreStep, error) {
+	steps := make([]restoreStep, 0, len(backupSections))
+	replace := mode == models.RestoreReplace
+
+	if data.Settings != nil {
+		step := unchangedStep("settings")
+		if replace || !bs.storage.Exists("settings") {
+			var err error
+			if step, err = bs.settings.stageRestore(*data.Settings); err != nil {
+				return nil, err
+			}
+		}
+		steps = append(steps, step)
+	}
+
+	if data.CurrentLocation != nil {
+		step := unchangedStep("currentLocation")
+		if replace || bs.locations.GetCurrentLocation() == nil {
+			var err error
+			if step, err = bs.locations.stageCurrentLocation(*data.CurrentLocation); err != nil {
+				return nil, err
+			}
+		}
+		steps = append(steps, step)
+	}
+
+	step, locationIDs, err := bs.locations.stageImport(data.SavedLocations, mode)
+	if err != nil {
+		return nil, err
+	}
+	steps = append(steps, step)
+
+	step, mosqueIDs, err := bs.mosques.stageImport(data.Mosques, mode)
+	if err != nil {
+		return nil, err
+	}
+	steps = append(steps, step)
+
+	step, alarmIDs, err := bs.alarms.stageImport(data.Alarms, mosqueIDs, mode)
+	if err != nil {
+		return nil, err
+	}
+	steps = append(steps, step)
+
+	// Profiles are staged even without any in the backup, since on replace
+	// the current ones refer to alarms that are gone
+	if step, err = bs.profiles.stageImport(data.AlarmProfiles, alarmIDs, locationIDs, mode); err != nil {
+		return nil, err
+	}
+	steps = append(steps, step)
+
+	if data.Timetables != nil {
+		if step, err = bs.timetables.stageImport(data.Timetables, mode); err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+
+	if step, err = bs.prayerLog.stageImport(data.PrayerLog, mode); err != nil {
+		return nil, err
+	}
+	steps = append(steps, step)
+
+	if step, err = bs.alarmEvents.stageImport(data.AlarmEvents, alarmIDs, mode); err != nil {
+		return nil, err
+	}
+	steps = append(steps, step)
+
+	if data.Qada != nil {
+		step := unchangedStep("qada")
+		if replace || !bs.storage.Exists("qada") {
+			if step, err = bs.qada.stageRestore(*data.Qada); err != nil {
+				return nil, err
+			}
+		}
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+// validate checks every item in a backup, reporting all problems at once.
+// Field paths are relative to the backup data, e.g. "alarms[2].anchor".
+func (bs *BackupService) validate(data models.BackupData) error {
+	var errs models.ValidationErrors
+	addAll := func(prefix string, err error) {
+		if err == nil {
+			return
+		}
+		appErr := models.AsAppError(err)
+		if len(appErr.Fields) == 0 {
+			errs.Add(prefix, "%s", appErr.Message)
+			return
+		}
+		for _, f := range appErr.Fields {
+			errs.Add(prefix+"."+f.Field, "%s", f.Message)
+		}
+	}
+
+	if data.Settings != nil {
+		addAll("settings", data.Settings.Validate())
+	}
+	if data.CurrentLocation != nil {
+		addAll("currentLocation", data.CurrentLocation.Validate())
+	}
+	for i := range data.SavedLocations {
+		addAll(fmt.Sprintf("savedLocations[%d]", i), data.SavedLocations[i].Validate())
+	}
+	for i := range data.Mosques {
+		mosque := data.Mosques[i].Clone()
+		addAll(fmt.Sprintf("mosques[%d]", i), bs.mosques.prepare(&mosque))
+	}
+	for i := range data.Alarms {
+		alarm := data.Alarms[i].Clone()
+		alarm.NormalizeAnchor()
+		addAll(fmt.Sprintf("alarms[%d]", i), alarm.Validate())
+	}
+	alarmIDs := make(map[int]bool, len(data.Alarms))
+	for _, alarm := range data.Alarms {
+		alarmIDs[alarm.ID] = true
+	}
+	locationIDs := make(map[int]bool, len(data.SavedLocations))
+	for _, location := range data.SavedLocations {
+		locationIDs[location.ID] = true
+	}
+	for i := range data.AlarmProfiles {
+		prefix := fmt.Sprintf("alarmProfiles[%d]", i)
+		profile := data.AlarmProfiles[i].Clone()
+		addAll(prefix, profile.Validate())
+		for _, id := range profile.AlarmIDs {
+			if !alarmIDs[id] {
+				errs.Add(prefix+".alarmIds", "alarm %d is not in the backup", id)
+			}
+		}
+		if id := profile.AutoActivate.LocationID; id != 0 && !locationIDs[id] {
+			errs.Add(prefix+".autoActivate.locationId", "saved location %d is not in the backup", id)
+		}
+	}
+	for i := range data.Timetables {
+		addAll(fmt.Sprintf("timetables[%d]", i), data.Timetables[i].Validate())
+	}
+	for i, e := range data.PrayerLog {
+		prefix := fmt.Sprintf("prayerLog[%d]", i)
+		if _, err := time.Parse(dateLayout, e.Date); err != nil {
+			errs.Add(prefix+".date", "invalid date %q", e.Date)
+		}
+		if !isKnownPrayer(e.Prayer) {
+			errs.Add(prefix+".prayer", "unknown prayer %q", e.Prayer)
+		}
+		if !e.Status.IsValid() {
+			errs.Add(prefix+".status", "unknown prayer status %q", e.Status)
+		}
+	}
+	for i, e := range data.AlarmEvents {
+		prefix := fmt.Sprintf("alarmEvents[%d]", i)
+		if !e.Type.IsValid() {
+			errs.Add(prefix+".type", "unknown alarm event type %q", e.Type)
+		}
+		if _, err := time.Parse(time.RFC3339, e.ScheduledAt); err != nil {
+			errs.Add(prefix+".scheduledAt", "invalid scheduled time %q", e.ScheduledAt)
+		}
+	}
+	return errs.Err()
+}
+
+// readBackup reads a backup file, verifies its checksum and upgrades its
+// data to the current schema version.
+func readBackup(path string) (models.Backup, models.BackupData, error) {
+	var backup models.Backup
+	var data models.BackupData
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return backup, data, models.NotFoundError("backup file %s not found", path)
+		}
+		return backup, data, models.StorageError(err)
+	}
+	if err := json.Unmarshal(raw, &backup); err != nil || backup.Format != models.BackupFormat {
+		return backup, data, models.ValidationError("%s is not an AzanAlarm backup", path)
+	}
+	if backup.Version > models.BackupVersion {
+		return backup, data, models.ValidationError(
+			"the backup was made by a newer version of AzanAlarm (format %d, supported %d)",
+			backup.Version, models.BackupVersion)
+	}
+	checksum, err := backupChecksum(backup.Data)
+	if err != nil || checksum != backup.Checksum {
+		return backup, data, models.ValidationError("the backup checksum does not match, the file is damaged or was edited")
+	}
+
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(backup.Data, &sections); err != nil {
+		return backup, data, models.ValidationError("the backup data is malformed: %v", err)
+	}
+	for name, section := range sections {
+		key, ok := backupSections[name]
+		if !ok {
+			continue
+		}
+		migrated, err := migrate(key, section, backup.SchemaVersion)
+		if err != nil {
+			return backup, data, err
+		}
+		sections[name] = migrated
+	}
+	upgraded, err := json.Marshal(sections)
+	if err != nil {
+		return backup, data, err
+	}
+	if err := json.Unmarshal(upgraded, &data); err != nil {
+		return backup, data, models.ValidationError("the backup data is malformed: %v", err)
+	}
+	return backup, data, nil
+}
+
+// backupChecksum returns the checksum of backup data, ignoring whitespace.
+func backupChecksum(data []byte) (string, error) {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(compact.Bytes())
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"AzanAlarm/internal/models"
+)
+
+// newTestCore creates all services on an in-memory backend holding the
+// given raw data.
+func newTestCore(t *testing.T, raw map[string]string) *Core {
+	t.Helper()
+	backend := NewMemoryBackend()
+	for key, data := range raw {
+		if err := backend.Write(key, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewCore(NewStorageServiceWithBackend(backend, ""))
+}
+
+// createLabelledAlarms creates one Fajr alarm per label.
+func createLabelledAlarms(t *testing.T, c *Core, labels ...string) []models.Alarm {
+	t.Helper()
+	alarms := make([]models.Alarm, 0, len(labels))
+	for i, label := range labels {
+		alarm := models.NewAlarm(models.Fajr, i)
+		alarm.Label = label
+		created, err := c.Alarms.CreateAlarm(alarm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		alarms = append(alarms, created)
+	}
+	return alarms
+}
+
+// exportTestBackup writes a backup with alarms "a" and "b", a profile
+// holding "b" and a timetable.
+func exportTestBackup(t *testing.T) string {
+	t.Helper()
+	source := newTestCore(t, nil)
+	alarms := createLabelledAlarms(t, source, "a", "b")
+	if _, err := source.Profiles.CreateProfile(models.AlarmProfile{Name: "Travel", AlarmIDs: []int{alarms[1].ID}}); err != nil {
+		t.Fatal(err)
+	}
+	source.Timetables.timetables = []models.Timetable{{
+		ID:        1,
+		Name:      "Central Mosque",
+		Latitude:  51.5,
+		Longitude: -0.1,
+		Entries:   []models.TimetableEntry{{Date: "2026-10-18", Fajr: "05:40", Isha: "19:30"}},
+	}}
+
+	path := filepath.Join(t.TempDir(), "backup.json")
+	if err := source.Backup.Export(path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// profileLabels returns the labels of the alarms in the named profile.
+func profileLabels(t *testing.T, c *Core, name string) []string {
+	t.Helper()
+	for _, p := range c.Profiles.GetProfiles() {
+		if p.Name != name {
+			continue
+		}
+		labels := make([]string, 0, len(p.AlarmIDs))
+		for _, id := range p.AlarmIDs {
+			alarm := c.Alarms.GetAlarm(id)
+			if alarm == nil {
+				t.Fatalf("profile %q refers to missing alarm %d", name, id)
+			}
+			labels = append(labels, alarm.Label)
+		}
+		return labels
+	}
+	t.Fatalf("profile %q not found", name)
+	return nil
+}
+
+func TestImportBackupRestoresProfiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     models.RestoreMode
+		profiles int
+	}{
+		{name: "replace", mode: models.RestoreReplace, profiles: 1},
+		{name: "merge", mode: models.RestoreMerge, profiles: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := exportTestBackup(t)
+
+			// The alarms here have other IDs than those in the backup
+			target := newTestCore(t, nil)
+			existing := createLabelledAlarms(t, target, "x", "y", "z")
+			if _, err := target.Profiles.CreateProfile(models.AlarmProfile{Name: "Home", AlarmIDs: []int{existing[0].ID}}); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := target.Backup.Import(path, tt.mode); err != nil {
+				t.Fatalf("import: %v", err)
+			}
+			if got := len(target.Profiles.GetProfiles()); got != tt.profiles {
+				t.Errorf("got %d profiles, want %d", got, tt.profiles)
+			}
+			if labels := profileLabels(t, target, "Travel"); len(labels) != 1 || labels[0] != "b" {
+				t.Errorf("restored profile holds alarms %v, want [b]", labels)
+			}
+			if tt.mode == models.RestoreMerge {
+				if labels := profileLabels(t, target, "Home"); len(labels) != 1 || labels[0] != "x" {
+					t.Errorf("existing profile holds alarms %v, want [x]", labels)
+				}
+			}
+			if timetables := target.Timetables.GetTimetables(); len(timetables) != 1 || timetables[0].Name != "Central Mosque" {
+				t.Errorf("got timetables %+v, want the one from the backup", timetables)
+			}
+		})
+	}
+}
+
+// TestImportBackupIsAtomic restores onto storage that refuses to save the
+// prayer log, which is committed after the alarms and profiles.
+func TestImportBackupIsAtomic(t *testing.T) {
+	path := exportTestBackup(t)
+
+	target := newTestCore(t, map[string]string{"prayer_log": `[{"date":`})
+	createLabelledAlarms(t, target, "x")
+	if _, err := target.Profiles.CreateProfile(models.AlarmProfile{Name: "Home", AlarmIDs: []int{1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := target.Backup.Import(path, models.RestoreReplace); !errors.Is(err, models.ErrStorageUnavailable) {
+		t.Fatalf("got %v, want a storage error", err)
+	}
+
+	// Both the services and storage are left as they were
+	reloaded := NewCore(target.Storage)
+	for _, c := range []*Core{target, reloaded} {
+		if alarms := c.Alarms.GetAlarms(); len(alarms) != 1 || alarms[0].Label != "x" {
+			t.Errorf("got alarms %+v, want only x", alarms)
+		}
+		if labels := profileLabels(t, c, "Home"); len(labels) != 1 || labels[0] != "x" {
+			t.Errorf("profile holds alarms %v, want [x]", labels)
+		}
+		if timetables := c.Timetables.GetTimetables(); len(timetables) != 0 {
+			t.Errorf("got timetables %+v, want none", timetables)
+		}
+	}
+}
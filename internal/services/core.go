@@ -0,0 +1,79 @@
+// Package services contains business logic for the AzanAlarm application.
+package services
+
+// Core holds the application services wired together on one storage. It is
+// shared by the desktop app and the command-line and daemon modes, which
+// run without a user interface.
+type Core struct {
+	Storage     *StorageService
+	Calculator  *PrayerCalculator
+	Locations   *LocationService
+	Alarms      *AlarmService
+	Settings    *SettingsService
+	Qibla       *QiblaService
+	Mosques     *MosqueService
+	Timetables  *TimetableService
+	Schedule    *ScheduleService
+	AlarmEvents *AlarmEventService
+	Profiles    *ProfileService
+	PrayerLog   *PrayerLogService
+	Qada        *QadaService
+	Backup      *BackupService
+	Sync        *SyncService
+}
+
+// NewCore creates all services on top of storage. Services whose data
+// cannot be loaded start empty; storage reports them through Warnings and
+// refuses to save over their data until it is fixed or discarded.
+func NewCore(storage *StorageService) *Core {
+	c := &Core{Storage: storage}
+
+	c.Calculator = NewPrayerCalculator()
+	c.Locations = NewLocationService(storage)
+	c.Alarms = NewAlarmService(storage)
+	c.Settings = NewSettingsService(storage)
+	c.Qibla = NewQiblaService()
+	c.Mosques = NewMosqueService(storage, c.Alarms)
+	c.Timetables = NewTimetableService(storage, c.Calculator, c.Settings)
+	c.Schedule = NewScheduleService(
+		c.Calculator,
+		c.Locations,
+		c.Settings,
+		c.Alarms,
+		c.Mosques,
+		c.Timetables,
+	)
+	c.AlarmEvents = NewAlarmEventService(storage)
+	c.Profiles = NewProfileService(storage, c.Alarms, c.Locations)
+	c.PrayerLog = NewPrayerLogService(storage)
+	c.Qada = NewQadaService(storage, c.PrayerLog)
+	c.Backup = NewBackupService(
+		storage,
+		c.Settings,
+		c.Locations,
+		c.Mosques,
+		c.Alarms,
+		c.Profiles,
+		c.Timetables,
+		c.PrayerLog,
+		c.AlarmEvents,
+		c.Qada,
+	)
+	c.Sync = NewSyncService(storage, c.Settings, c.Alarms, c.Locations, c.Profiles)
+
+	return c
+}
+
+// OpenCore opens the storage in the data directory picked by
+// ResolveDataDir and creates all services on top of it.
+func OpenCore(dataDirOverride string) (*Core, error) {
+	dataDir, _, err := ResolveDataDir(dataDirOverride)
+	if err != nil {
+		return nil, err
+	}
+	storage, err := NewStorageService(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	return NewCore(storage), nil
+}
@@ -0,0 +1,68 @@
+// Package services contains business logic for the AzanAlarm application.
+package services
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// DataDirEnv is the environment variable that overrides the data directory.
+const DataDirEnv = "AZANALARM_DATA_DIR"
+
+// PortableMarker is the file that, placed next to the executable, switches
+// the app to portable mode: data is kept in PortableDataDir beside it.
+const PortableMarker = "azanalarm.portable"
+
+// PortableDataDir is the data directory used in portable mode, relative to
+// the executable.
+const PortableDataDir = "AzanAlarmData"
+
+// Data directory sources, reported by ResolveDataDir.
+const (
+	DataDirFromFlag     = "flag"
+	DataDirFromEnv      = "env"
+	DataDirFromPortable = "portable"
+	DataDirFromDefault  = "default"
+)
+
+// ResolveDataDir picks the data directory. In order of precedence it is the
+// given override, e.g. from a command-line flag, the AZANALARM_DATA_DIR
+// environment variable, the portable directory if the marker file exists
+// next to the executable, and finally AzanAlarm in the user's config
+// directory. It also returns which of these was used.
+func ResolveDataDir(override string) (string, string, error) {
+	if override != "" {
+		dir, err := filepath.Abs(override)
+		return dir, DataDirFromFlag, err
+	}
+	if env := os.Getenv(DataDirEnv); env != "" {
+		dir, err := filepath.Abs(env)
+		return dir, DataDirFromEnv, err
+	}
+	if dir, ok := portableDataDir(); ok {
+		return dir, DataDirFromPortable, nil
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", "", err
+	}
+	return filepath.Join(configDir, "AzanAlarm"), DataDirFromDefault, nil
+}
+
+// portableDataDir returns the portable data directory if the marker file
+// exists next to the executable.
+func portableDataDir() (string, bool) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", false
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	exeDir := filepath.Dir(exe)
+	if _, err := os.Stat(filepath.Join(exeDir, PortableMarker)); err != nil {
+		return "", false
+	}
+	return filepath.Join(exeDir, PortableDataDir), true
+}
@@ -3,18 +3,23 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	"AzanAlarm/internal/models"
 )
 
-// LocationService handles location-related operations.
+// LocationService handles location-related operations. It is safe for
+// concurrent use.
 type LocationService struct {
 	storage    *StorageService
 	httpClient *http.Client
+	mu         sync.Mutex // Serialises read-modify-write of saved locations
 }
 
 // NominatimResult represents a result from the Nominatim API.
@@ -62,13 +67,16 @@ func (ls *LocationService) SearchLocations(query string) ([]models.Location, err
 
 	resp, err := ls.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, models.NetworkError(err)
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, models.NetworkError(fmt.Errorf("geocoding service returned %s", resp.Status))
+	}
 
 	var results []NominatimResult
 	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
-		return nil, err
+		return nil, models.NetworkError(err)
 	}
 
 	locations := make([]models.Location, 0, len(results))
@@ -120,13 +128,16 @@ func (ls *LocationService) ReverseGeocode(lat, lon float64) (*models.Location, e
 
 	resp, err := ls.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, models.NetworkError(err)
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, models.NetworkError(fmt.Errorf("geocoding service returned %s", resp.Status))
+	}
 
 	var result NominatimResult
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
+		return nil, models.NetworkError(err)
 	}
 
 	name := result.Address.City
@@ -162,6 +173,9 @@ func (ls *LocationService) GetCurrentLocation() *models.Location {
 
 // SetCurrentLocation sets the current active location.
 func (ls *LocationService) SetCurrentLocation(location models.Location) error {
+	if err := location.Validate(); err != nil {
+		return err
+	}
 	location.IsCurrent = true
 	return ls.storage.Save("current_location", location)
 }
@@ -177,6 +191,13 @@ func (ls *LocationService) GetSavedLocations() []models.Location {
 
 // SaveLocation adds a location to saved locations.
 func (ls *LocationService) SaveLocation(location models.Location) error {
+	if err := location.Validate(); err != nil {
+		return err
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
 	locations := ls.GetSavedLocations()
 
 	// Check if location already exists
@@ -186,13 +207,19 @@ func (ls *LocationService) SaveLocation(location models.Location) error {
 		}
 	}
 
-	location.ID = len(locations) + 1
+	location.ID = nextLocationID(locations)
+	if location.CreatedAt == 0 {
+		location.CreatedAt = time.Now().UnixMilli()
+	}
 	locations = append(locations, location)
 	return ls.storage.Save("saved_locations", locations)
 }
 
 // DeleteLocation removes a location from saved locations.
 func (ls *LocationService) DeleteLocation(id int) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
 	locations := ls.GetSavedLocations()
 	newLocations := make([]models.Location, 0, len(locations))
 
@@ -201,10 +228,161 @@ func (ls *LocationService) DeleteLocation(id int) error {
 			newLocations = append(newLocations, loc)
 		}
 	}
+	if len(newLocations) == len(locations) {
+		return models.NotFoundError("location %d not found", id)
+	}
 
 	return ls.storage.Save("saved_locations", newLocations)
 }
 
+// stageImport computes the saved locations after restoring a backup,
+// without changing anything until the returned step is committed. In merge
+// mode, locations already saved are skipped and the rest get new IDs. It
+// also returns the ID each imported location ends up with.
+func (ls *LocationService) stageImport(locations []models.Location, mode models.RestoreMode) (restoreStep, map[int]int, error) {
+	counts := models.RestoreCounts{Section: "savedLocations"}
+	ids := make(map[int]int, len(locations))
+	for _, location := range locations {
+		if err := location.Validate(); err != nil {
+			return restoreStep{}, nil, err
+		}
+	}
+
+	saved := ls.GetSavedLocations()
+	if mode == models.RestoreReplace {
+		counts.Removed = len(saved)
+		saved = []models.Location{}
+	}
+	nextID := nextLocationID(saved)
+
+	for _, location := range locations {
+		duplicate := false
+		for _, loc := range saved {
+			if loc.IsSameLocation(location) {
+				ids[location.ID] = loc.ID
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			counts.Unchanged++
+			continue
+		}
+		ids[location.ID] = nextID
+		location.ID = nextID
+		nextID++
+		saved = append(saved, location)
+		counts.Added++
+	}
+
+	var previous []models.Location
+	return restoreStep{
+		counts: counts,
+		commit: func() error {
+			ls.mu.Lock()
+			defer ls.mu.Unlock()
+
+			previous = ls.GetSavedLocations()
+			return ls.storage.Save("saved_locations", saved)
+		},
+		undo: func() error {
+			ls.mu.Lock()
+			defer ls.mu.Unlock()
+
+			return ls.storage.Save("saved_locations", previous)
+		},
+	}, ids, nil
+}
+
+// stageCurrentLocation returns the step that restores the current location
+// from a backup.
+func (ls *LocationService) stageCurrentLocation(location models.Location) (restoreStep, error) {
+	if err := location.Validate(); err != nil {
+		return restoreStep{}, err
+	}
+	location.IsCurrent = true
+
+	var previous *models.Location
+	return restoreStep{
+		counts: models.RestoreCounts{Section: "currentLocation", Updated: 1},
+		commit: func() error {
+			previous = ls.GetCurrentLocation()
+			return ls.storage.Save("current_location", location)
+		},
+		undo: func() error {
+			if previous == nil {
+				if err := ls.storage.Delete("current_location"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+					return err
+				}
+				return nil
+			}
+			return ls.storage.Save("current_location", *previous)
+		},
+	}, nil
+}
+
+// ApplySync applies the outcome of a sync: locations are added unless
+// already saved, and deleted by sync key.
+func (ls *LocationService) ApplySync(adds []models.Location, deletes []string) (models.SyncResult, error) {
+	var result models.SyncResult
+	for _, location := range adds {
+		if err := location.Validate(); err != nil {
+			return result, err
+		}
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	deleted := make(map[string]bool, len(deletes))
+	for _, key := range deletes {
+		deleted[key] = true
+	}
+	saved := ls.GetSavedLocations()
+	locations := make([]models.Location, 0, len(saved)+len(adds))
+	for _, loc := range saved {
+		if deleted[loc.SyncKey()] {
+			result.LocationsDeleted++
+			continue
+		}
+		locations = append(locations, loc)
+	}
+	for _, location := range adds {
+		duplicate := false
+		for _, loc := range locations {
+			if loc.IsSameLocation(location) {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+		location.ID = nextLocationID(locations)
+		locations = append(locations, location)
+		result.LocationsAdded++
+	}
+
+	if !result.Changed() {
+		return result, nil
+	}
+	if err := ls.storage.Save("saved_locations", locations); err != nil {
+		return models.SyncResult{}, err
+	}
+	return result, nil
+}
+
+// nextLocationID returns an ID not used by any of the locations.
+func nextLocationID(locations []models.Location) int {
+	next := 1
+	for _, loc := range locations {
+		if loc.ID >= next {
+			next = loc.ID + 1
+		}
+	}
+	return next
+}
+
 // formatFloat formats a float64 for URL parameters.
 func formatFloat(f float64) string {
 	return fmt.Sprintf("%f", f)
@@ -0,0 +1,143 @@
+// Package services contains business logic for the AzanAlarm application.
+package services
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"AzanAlarm/internal/models"
+)
+
+// CompareMethods runs every calculation method and juristic school over
+// the dates of an imported timetable and ranks them by how closely they
+// match it, best first.
+func (ts *TimetableService) CompareMethods(timetableID int) (models.MethodComparisonReport, error) {
+	timetable := ts.GetTimetable(timetableID)
+	if timetable == nil {
+		return models.MethodComparisonReport{}, models.NotFoundError("timetable %d not found", timetableID)
+	}
+	if len(timetable.Entries) == 0 {
+		return models.MethodComparisonReport{}, models.ValidationError("timetable %q has no entries", timetable.Name)
+	}
+
+	report := models.MethodComparisonReport{
+		TimetableID:   timetable.ID,
+		TimetableName: timetable.Name,
+		From:          timetable.Entries[0].Date,
+		To:            timetable.Entries[len(timetable.Entries)-1].Date,
+		Dates:         len(timetable.Entries),
+		Results:       make([]models.MethodComparison, 0),
+		Adjustments:   make(map[models.Prayer]int),
+	}
+
+	for _, method := range models.AllCalculationMethods() {
+		for _, juristic := range []models.JuristicMethod{models.Shafii, models.Hanafi} {
+			report.Results = append(report.Results, ts.compareMethod(timetable, method, juristic))
+		}
+	}
+
+	sort.SliceStable(report.Results, func(i, j int) bool {
+		a, b := report.Results[i], report.Results[j]
+		if a.MeanMinutes != b.MeanMinutes {
+			return a.MeanMinutes < b.MeanMinutes
+		}
+		return a.MaxMinutes < b.MaxMinutes
+	})
+	for i := range report.Results {
+		report.Results[i].Rank = i + 1
+	}
+
+	report.Recommended = report.Results[0]
+	for _, p := range report.Recommended.Prayers {
+		report.Adjustments[p.Prayer] = int(math.Round(p.MeanSignedMinutes))
+	}
+
+	return report, nil
+}
+
+// compareMethod measures the deviation of one method and juristic school
+// from the timetable.
+func (ts *TimetableService) compareMethod(
+	timetable *models.Timetable,
+	method models.PrayerCalculationMethod,
+	juristic models.JuristicMethod,
+) models.MethodComparison {
+	type totals struct {
+		count     int
+		absSum    float64
+		signedSum float64
+		max       int
+	}
+	byPrayer := make(map[models.Prayer]*totals)
+	for _, prayer := range models.AllPrayers() {
+		byPrayer[prayer] = &totals{}
+	}
+
+	for _, e := range timetable.Entries {
+		date, err := time.ParseInLocation(dateLayout, e.Date, time.Local)
+		if err != nil {
+			continue
+		}
+		calculated := ts.calculator.Calculate(
+			timetable.Latitude,
+			timetable.Longitude,
+			date,
+			method,
+			juristic,
+			timezoneOffsetFor(date),
+		)
+		for _, prayer := range models.AllPrayers() {
+			official, ok := officialTime(e, prayer, date)
+			if !ok {
+				continue
+			}
+			calc, ok := calculated.ParseTime(prayer)
+			if !ok {
+				continue
+			}
+			delta := int(official.Sub(calc).Minutes())
+			t := byPrayer[prayer]
+			t.count++
+			t.absSum += float64(abs(delta))
+			t.signedSum += float64(delta)
+			if abs(delta) > t.max {
+				t.max = abs(delta)
+			}
+		}
+	}
+
+	result := models.MethodComparison{
+		Method:         method,
+		MethodName:     method.DisplayName(),
+		JuristicMethod: juristic,
+		Prayers:        make([]models.PrayerDeviation, 0, 5),
+	}
+	count, absSum := 0, 0.0
+	for _, prayer := range models.AllPrayers() {
+		t := byPrayer[prayer]
+		if t.count == 0 {
+			continue
+		}
+		result.Prayers = append(result.Prayers, models.PrayerDeviation{
+			Prayer:            prayer,
+			MeanMinutes:       roundTenth(t.absSum / float64(t.count)),
+			MaxMinutes:        t.max,
+			MeanSignedMinutes: roundTenth(t.signedSum / float64(t.count)),
+		})
+		count += t.count
+		absSum += t.absSum
+		if t.max > result.MaxMinutes {
+			result.MaxMinutes = t.max
+		}
+	}
+	if count > 0 {
+		result.MeanMinutes = roundTenth(absSum / float64(count))
+	}
+	return result
+}
+
+// roundTenth rounds a value to one decimal place.
+func roundTenth(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,99 @@
+// Package services contains business logic for the AzanAlarm application.
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"AzanAlarm/internal/models"
+)
+
+// SchemaVersion is the version of the data written by Save. Increase it
+// and register a migration for every key whose format changes.
+const SchemaVersion = 1
+
+// envelope wraps every saved data file with the schema version of its
+// payload. Files saved before versioning have no envelope and count as
+// version 0.
+type envelope struct {
+	Version int             `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// migration upgrades the payload of one storage key by one version.
+type migration func(data json.RawMessage) (json.RawMessage, error)
+
+// migrations holds, for each version, the steps that upgrade payloads from
+// it to the next version. Keys without a step are carried over unchanged.
+var migrations = map[int]map[string]migration{
+	0: {
+		"alarms": migrateAlarmAnchors,
+	},
+}
+
+// unwrap extracts the payload and its version from a data file.
+func unwrap(raw []byte) (json.RawMessage, int) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil || len(fields) != 2 || fields["data"] == nil {
+		return raw, 0
+	}
+	var version int
+	if err := json.Unmarshal(fields["version"], &version); err != nil {
+		return raw, 0
+	}
+	return fields["data"], version
+}
+
+// migrate upgrades the payload of a key from the given version to
+// SchemaVersion.
+func migrate(key string, data json.RawMessage, version int) (json.RawMessage, error) {
+	if version > SchemaVersion {
+		return nil, models.StorageError(fmt.Errorf(
+			"%s was written by a newer version of AzanAlarm (schema %d, supported %d)",
+			key, version, SchemaVersion))
+	}
+	for v := version; v < SchemaVersion; v++ {
+		step, ok := migrations[v][key]
+		if !ok {
+			continue
+		}
+		migrated, err := step(data)
+		if err != nil {
+			return nil, models.StorageError(fmt.Errorf("migrating %s from schema %d: %w", key, v, err))
+		}
+		data = migrated
+	}
+	return data, nil
+}
+
+// migrateAlarmAnchors gives alarms saved before anchors existed a prayer
+// anchor matching their prayer.
+func migrateAlarmAnchors(data json.RawMessage) (json.RawMessage, error) {
+	var alarms []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &alarms); err != nil {
+		return nil, err
+	}
+	for _, alarm := range alarms {
+		var anchor models.AlarmAnchor
+		if raw, ok := alarm["anchor"]; ok {
+			if err := json.Unmarshal(raw, &anchor); err != nil {
+				return nil, err
+			}
+		}
+		if anchor.Type != "" {
+			continue
+		}
+		var prayer models.Prayer
+		if raw, ok := alarm["prayer"]; ok {
+			if err := json.Unmarshal(raw, &prayer); err != nil {
+				return nil, err
+			}
+		}
+		encoded, err := json.Marshal(models.PrayerAnchor(prayer))
+		if err != nil {
+			return nil, err
+		}
+		alarm["anchor"] = encoded
+	}
+	return json.Marshal(alarms)
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"AzanAlarm/internal/models"
+)
+
+func TestMigrateAlarmAnchors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []models.AlarmAnchor
+	}{
+		{
+			name: "prayer alarm without anchor",
+			data: `[{"id":1,"prayer":"fajr","offsetMinutes":-10}]`,
+			want: []models.AlarmAnchor{models.PrayerAnchor(models.Fajr)},
+		},
+		{
+			name: "empty anchor",
+			data: `[{"id":1,"prayer":"isha","anchor":{"type":""}}]`,
+			want: []models.AlarmAnchor{models.PrayerAnchor(models.Isha)},
+		},
+		{
+			name: "existing anchor is kept",
+			data: `[{"id":1,"prayer":"dhuhr","anchor":{"type":"clock","clockTime":"05:30"}}]`,
+			want: []models.AlarmAnchor{{Type: models.AnchorClock, ClockTime: "05:30"}},
+		},
+		{
+			name: "several alarms",
+			data: `[{"id":1,"prayer":"asr"},{"id":2,"prayer":"maghrib","anchor":{"type":"jumuah"}}]`,
+			want: []models.AlarmAnchor{
+				models.PrayerAnchor(models.Asr),
+				{Type: models.AnchorJumuah},
+			},
+		},
+		{
+			name: "no alarms",
+			data: `[]`,
+			want: []models.AlarmAnchor{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrated, err := migrate("alarms", json.RawMessage(tt.data), 0)
+			if err != nil {
+				t.Fatalf("migrate: %v", err)
+			}
+			var alarms []models.Alarm
+			if err := json.Unmarshal(migrated, &alarms); err != nil {
+				t.Fatalf("decoding migrated alarms: %v", err)
+			}
+			if len(alarms) != len(tt.want) {
+				t.Fatalf("got %d alarms, want %d", len(alarms), len(tt.want))
+			}
+			for i, alarm := range alarms {
+				if alarm.Anchor != tt.want[i] {
+					t.Errorf("alarm %d: got anchor %+v, want %+v", alarm.ID, alarm.Anchor, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMigrateAlarmAnchorsRejectsInvalidData(t *testing.T) {
+	if _, err := migrate("alarms", json.RawMessage(`{"id":1}`), 0); !errors.Is(err, models.ErrStorageUnavailable) {
+		t.Errorf("got %v, want a storage error", err)
+	}
+}
+
+func TestLoadLegacySettingsKeepsNewDefaults(t *testing.T) {
+	tests := []struct {
+		name  string
+		saved string
+		check func(t *testing.T, s models.AppSettings)
+	}{
+		{
+			name:  "unversioned file",
+			saved: `{"calculationMethod":"isna","is24HourFormat":true}`,
+			check: func(t *testing.T, s models.AppSettings) {
+				if s.CalculationMethod != models.ISNA || !s.Is24HourFormat {
+					t.Errorf("saved values were lost: %+v", s)
+				}
+			},
+		},
+		{
+			name:  "missing Jumuah settings",
+			saved: `{"version":1,"data":{"calculationMethod":"isna"}}`,
+			check: func(t *testing.T, s models.AppSettings) {
+				if !s.Jumuah.Enabled {
+					t.Error("Jumuah alarms should default to enabled")
+				}
+			},
+		},
+		{
+			name:  "missing forbidden windows",
+			saved: `{"juristicMethod":"hanafi"}`,
+			check: func(t *testing.T, s models.AppSettings) {
+				want := models.DefaultSettings().ForbiddenWindows
+				if s.ForbiddenWindows != want {
+					t.Errorf("got forbidden windows %+v, want %+v", s.ForbiddenWindows, want)
+				}
+				if s.JuristicMethod != models.Hanafi {
+					t.Errorf("got juristic method %q, want hanafi", s.JuristicMethod)
+				}
+			},
+		},
+		{
+			name:  "missing API settings",
+			saved: `{"theme":"dark"}`,
+			check: func(t *testing.T, s models.AppSettings) {
+				if s.API.Address != models.DefaultAPIAddress || s.API.Enabled {
+					t.Errorf("got API settings %+v, want the defaults", s.API)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := NewMemoryBackend()
+			if err := backend.Write("settings", []byte(tt.saved)); err != nil {
+				t.Fatal(err)
+			}
+			ss := NewSettingsService(NewStorageServiceWithBackend(backend, ""))
+			settings := ss.GetSettings()
+			if err := settings.Validate(); err != nil {
+				t.Errorf("loaded settings are invalid: %v", err)
+			}
+			tt.check(t, settings)
+		})
+	}
+}
+
+func TestLoadRejectsNewerSchema(t *testing.T) {
+	const saved = `{"version":99,"data":[{"id":7,"prayer":"fajr","label":"from the future"}]}`
+
+	backend := NewMemoryBackend()
+	if err := backend.Write("alarms", []byte(saved)); err != nil {
+		t.Fatal(err)
+	}
+	storage := NewStorageServiceWithBackend(backend, "")
+
+	var alarms []models.Alarm
+	if err := storage.Load("alarms", &alarms); !errors.Is(err, models.ErrStorageUnavailable) {
+		t.Fatalf("got %v, want a storage error", err)
+	}
+
+	// The services start without the alarms but must not save over them
+	as := NewAlarmService(storage)
+	if _, err := as.CreateAlarm(models.NewAlarm(models.Dhuhr, 0)); !errors.Is(err, models.ErrStorageUnavailable) {
+		t.Errorf("creating an alarm: got %v, want a storage error", err)
+	}
+	raw, err := backend.Read("alarms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != saved {
+		t.Errorf("alarms were overwritten with %s", raw)
+	}
+}
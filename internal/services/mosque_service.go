@@ -0,0 +1,357 @@
+// Package services contains business logic for the AzanAlarm application.
+package services
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"AzanAlarm/internal/models"
+)
+
+// MosqueService manages mosques and their iqamah schedules. It is safe for
+// concurrent use.
+type MosqueService struct {
+	storage *StorageService
+	alarms  *AlarmService
+	mu      sync.RWMutex
+	mosques []models.Mosque
+	nextID  int
+}
+
+// NewMosqueService creates a new MosqueService instance.
+func NewMosqueService(storage *StorageService, alarms *AlarmService) *MosqueService {
+	ms := &MosqueService{
+		storage: storage,
+		alarms:  alarms,
+		mosques: []models.Mosque{},
+		nextID:  1,
+	}
+
+	// Load existing mosques
+	var savedMosques []models.Mosque
+	if err := storage.Load("mosques", &savedMosques); err == nil {
+		ms.mosques = savedMosques
+		for _, m := range ms.mosques {
+			if m.ID >= ms.nextID {
+				ms.nextID = m.ID + 1
+			}
+		}
+	}
+
+	return ms
+}
+
+// Reload replaces the mosques with those in storage, e.g. after the file
+// was replaced outside the app. If any mosque is invalid, all are rejected
+// and the current ones are kept.
+func (ms *MosqueService) Reload() error {
+	var mosques []models.Mosque
+	if err := ms.storage.Load("mosques", &mosques); err != nil {
+		return err
+	}
+	for i := range mosques {
+		if err := ms.prepare(&mosques[i]); err != nil {
+			return models.ValidationError("mosque %d: %v", mosques[i].ID, err)
+		}
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.mosques = mosques
+	for _, m := range ms.mosques {
+		if m.ID >= ms.nextID {
+			ms.nextID = m.ID + 1
+		}
+	}
+	return nil
+}
+
+// GetMosques returns a copy of all mosques.
+func (ms *MosqueService) GetMosques() []models.Mosque {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	result := make([]models.Mosque, len(ms.mosques))
+	for i, m := range ms.mosques {
+		result[i] = m.Clone()
+	}
+	return result
+}
+
+// GetMosque returns a copy of a specific mosque by ID.
+func (ms *MosqueService) GetMosque(id int) *models.Mosque {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	if existing := ms.find(id); existing != nil {
+		mosque := existing.Clone()
+		return &mosque
+	}
+	return nil
+}
+
+// GetDefaultMosque returns a copy of the mosque used for the next-prayer
+// countdown, or nil if none is set.
+func (ms *MosqueService) GetDefaultMosque() *models.Mosque {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	for i := range ms.mosques {
+		if ms.mosques[i].IsDefault {
+			mosque := ms.mosques[i].Clone()
+			return &mosque
+		}
+	}
+	return nil
+}
+
+// CreateMosque creates a new mosque. The first mosque becomes the default.
+func (ms *MosqueService) CreateMosque(mosque models.Mosque) (models.Mosque, error) {
+	if err := ms.prepare(&mosque); err != nil {
+		return models.Mosque{}, err
+	}
+	mosque = mosque.Clone()
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	mosque.ID = ms.nextID
+	ms.nextID++
+	mosque.IsDefault = len(ms.mosques) == 0
+	now := time.Now().UnixMilli()
+	mosque.CreatedAt = now
+	mosque.UpdatedAt = now
+
+	ms.mosques = append(ms.mosques, mosque)
+	if err := ms.save(); err != nil {
+		return models.Mosque{}, err
+	}
+	return mosque.Clone(), nil
+}
+
+// UpdateMosque updates an existing mosque.
+func (ms *MosqueService) UpdateMosque(mosque models.Mosque) error {
+	if err := ms.prepare(&mosque); err != nil {
+		return err
+	}
+	mosque = mosque.Clone()
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	existing := ms.find(mosque.ID)
+	if existing == nil {
+		return models.NotFoundError("mosque %d not found", mosque.ID)
+	}
+	mosque.IsDefault = existing.IsDefault
+	mosque.CreatedAt = existing.CreatedAt
+	mosque.UpdatedAt = time.Now().UnixMilli()
+	*existing = mosque
+	return ms.save()
+}
+
+// DeleteMosque removes a mosque. If it was the default, the first
+// remaining mosque becomes the default. A mosque that iqamah alarms follow
+// is not deleted, since those alarms would never ring again.
+func (ms *MosqueService) DeleteMosque(id int) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.find(id) == nil {
+		return models.NotFoundError("mosque %d not found", id)
+	}
+	var errs models.ValidationErrors
+	for _, alarm := range ms.alarms.GetAlarms() {
+		if alarm.Anchor.Type == models.AnchorIqamah && alarm.Anchor.MosqueID == id {
+			errs.Add("alarms", "alarm %q follows this mosque's iqamah, change or delete it first", alarm.DisplayLabel())
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return err
+	}
+	newMosques := make([]models.Mosque, 0, len(ms.mosques))
+	hadDefault := false
+	for _, m := range ms.mosques {
+		if m.ID != id {
+			newMosques = append(newMosques, m)
+		} else if m.IsDefault {
+			hadDefault = true
+		}
+	}
+	// Keep a default as long as there are mosques left
+	if hadDefault && len(newMosques) > 0 {
+		newMosques[0].IsDefault = true
+	}
+	ms.mosques = newMosques
+	return ms.save()
+}
+
+// SetDefaultMosque marks a mosque as the default.
+func (ms *MosqueService) SetDefaultMosque(id int) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.find(id) == nil {
+		return models.NotFoundError("mosque %d not found", id)
+	}
+	for i := range ms.mosques {
+		ms.mosques[i].IsDefault = ms.mosques[i].ID == id
+	}
+	return ms.save()
+}
+
+// stageImport computes the mosques after restoring a backup, without
+// changing anything until the returned step is committed. In merge mode,
+// mosques with the name of an existing one are skipped and the rest get new
+// IDs. It also returns the ID each imported mosque ends up with.
+func (ms *MosqueService) stageImport(mosques []models.Mosque, mode models.RestoreMode) (restoreStep, map[int]int, error) {
+	counts := models.RestoreCounts{Section: "mosques"}
+	ids := make(map[int]int, len(mosques))
+
+	imported := make([]models.Mosque, 0, len(mosques))
+	for _, mosque := range mosques {
+		if err := ms.prepare(&mosque); err != nil {
+			return restoreStep{}, nil, err
+		}
+		imported = append(imported, mosque.Clone())
+	}
+
+	// The restored mosques are built in a copy of the service
+	ms.mu.RLock()
+	staged := &MosqueService{nextID: ms.nextID}
+	if mode == models.RestoreReplace {
+		counts.Removed = len(ms.mosques)
+		staged.mosques = make([]models.Mosque, 0, len(imported))
+		staged.nextID = 1
+		for _, mosque := range imported {
+			if mosque.ID >= staged.nextID {
+				staged.nextID = mosque.ID + 1
+			}
+		}
+	} else {
+		staged.mosques = append(make([]models.Mosque, 0, len(ms.mosques)+len(imported)), ms.mosques...)
+	}
+	ms.mu.RUnlock()
+	hasDefault := false
+	for _, m := range staged.mosques {
+		hasDefault = hasDefault || m.IsDefault
+	}
+
+	for _, mosque := range imported {
+		if mode == models.RestoreMerge {
+			if existing := staged.findByName(mosque.Name); existing != nil {
+				ids[mosque.ID] = existing.ID
+				counts.Unchanged++
+				continue
+			}
+		}
+		// Backup IDs are kept on replace, unless they clash
+		oldID := mosque.ID
+		if mode == models.RestoreMerge || mosque.ID <= 0 || staged.find(mosque.ID) != nil {
+			mosque.ID = staged.nextID
+			staged.nextID++
+		}
+		ids[oldID] = mosque.ID
+		mosque.IsDefault = mosque.IsDefault && !hasDefault
+		hasDefault = hasDefault || mosque.IsDefault
+		staged.mosques = append(staged.mosques, mosque)
+		counts.Added++
+	}
+	if !hasDefault && len(staged.mosques) > 0 {
+		staged.mosques[0].IsDefault = true
+	}
+
+	var previous []models.Mosque
+	var previousNextID int
+	return restoreStep{
+		counts: counts,
+		commit: func() error {
+			ms.mu.Lock()
+			defer ms.mu.Unlock()
+
+			previous, previousNextID = ms.mosques, ms.nextID
+			ms.mosques, ms.nextID = staged.mosques, staged.nextID
+			if err := ms.save(); err != nil {
+				ms.mosques, ms.nextID = previous, previousNextID
+				return err
+			}
+			return nil
+		},
+		undo: func() error {
+			ms.mu.Lock()
+			defer ms.mu.Unlock()
+
+			ms.mosques, ms.nextID = previous, previousNextID
+			return ms.save()
+		},
+	}, ids, nil
+}
+
+// IqamahTime returns the iqamah time of a prayer at a mosque on the given
+// date, where adhan is the calculated prayer time.
+func (ms *MosqueService) IqamahTime(mosqueID int, prayer models.Prayer, date time.Time, adhan time.Time) (time.Time, bool) {
+	mosque := ms.GetMosque(mosqueID)
+	if mosque == nil {
+		return time.Time{}, false
+	}
+	return mosque.IqamahTime(prayer, date, adhan)
+}
+
+// prepare validates a mosque and fills in empty collections.
+func (ms *MosqueService) prepare(mosque *models.Mosque) error {
+	mosque.Name = strings.TrimSpace(mosque.Name)
+	if mosque.Name == "" {
+		return models.ValidationError("mosque name is required")
+	}
+	if mosque.Rules == nil {
+		mosque.Rules = make(map[models.Prayer]models.IqamahRule)
+	}
+	for prayer, rule := range mosque.Rules {
+		if !isKnownPrayer(prayer) {
+			return models.ValidationError("unknown prayer %q", prayer)
+		}
+		if err := rule.Validate(); err != nil {
+			return models.ValidationError("%s: %v", prayer.DisplayName(), err)
+		}
+	}
+	if mosque.Jumuah.KhutbahTime != "" {
+		if _, err := time.Parse("15:04", mosque.Jumuah.KhutbahTime); err != nil {
+			return models.ValidationError("invalid khutbah time %q", mosque.Jumuah.KhutbahTime)
+		}
+	}
+	if mosque.Jumuah.IqamahTime != "" {
+		if _, err := time.Parse("15:04", mosque.Jumuah.IqamahTime); err != nil {
+			return models.ValidationError("invalid Jumu'ah iqamah time %q", mosque.Jumuah.IqamahTime)
+		}
+	}
+	return nil
+}
+
+// find returns the stored mosque with the ID, or nil. The caller must hold
+// the lock.
+func (ms *MosqueService) find(id int) *models.Mosque {
+	for i := range ms.mosques {
+		if ms.mosques[i].ID == id {
+			return &ms.mosques[i]
+		}
+	}
+	return nil
+}
+
+// findByName returns the stored mosque with the name, ignoring case, or
+// nil. The caller must hold the lock.
+func (ms *MosqueService) findByName(name string) *models.Mosque {
+	for i := range ms.mosques {
+		if strings.EqualFold(ms.mosques[i].Name, name) {
+			return &ms.mosques[i]
+		}
+	}
+	return nil
+}
+
+// save persists mosques to storage. The caller must hold the lock.
+func (ms *MosqueService) save() error {
+	return ms.storage.Save("mosques", ms.mosques)
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"AzanAlarm/internal/models"
+)
+
+func TestDeleteMosqueFollowedByIqamahAlarms(t *testing.T) {
+	c := newTestCore(t, nil)
+	mosque, err := c.Mosques.CreateMosque(models.Mosque{Name: "Central Mosque"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	alarm := models.NewAlarm(models.Asr, -10)
+	alarm.Anchor = models.AlarmAnchor{Type: models.AnchorIqamah, Prayer: models.Asr, MosqueID: mosque.ID}
+	alarm, err = c.Alarms.CreateAlarm(alarm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Mosques.DeleteMosque(mosque.ID); !errors.Is(err, models.ErrValidation) {
+		t.Fatalf("got %v, want a validation error", err)
+	}
+	if c.Mosques.GetMosque(mosque.ID) == nil {
+		t.Fatal("the mosque was deleted")
+	}
+
+	if err := c.Alarms.DeleteAlarm(alarm.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Mosques.DeleteMosque(mosque.ID); err != nil {
+		t.Errorf("deleting the unused mosque: %v", err)
+	}
+}
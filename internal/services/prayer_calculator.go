@@ -36,20 +36,7 @@ func (pc *PrayerCalculator) Calculate(
 	timezoneOffset float64,
 ) models.PrayerTimes {
 	params := pc.getCalculationParams(method)
-
-	// Calculate Julian date
-	jd := pc.calculateJulianDate(date)
-
-	// Days since J2000.0
-	d := jd - 2451545.0
-
-	// Calculate equation of time and solar declination
-	eqTime := pc.calculateEquationOfTime(d)
-	declination := pc.calculateSolarDeclination(d)
-
-	// Calculate Dhuhr first (solar noon) - calculate in UTC then we'll convert
-	// Dhuhr in UTC = 12:00 - longitude/15 - eqTime/60
-	dhuhrUTC := 12.0 - longitude/15.0 - eqTime/60.0
+	dhuhrUTC, declination := pc.solarNoon(longitude, date)
 
 	// Calculate other prayer times relative to Dhuhr (all in UTC)
 	fajrUTC := dhuhrUTC - pc.hourAngleForAngle(latitude, declination, params.FajrAngle)/15.0
@@ -67,6 +54,104 @@ func (pc *PrayerCalculator) Calculate(
 	}
 }
 
+// CalculateSunTimes computes sunrise, sunset and the night divisions for a
+// given location and date. The night runs from sunset into the next day:
+// midnight is halfway to the next sunrise, and the last third starts two
+// thirds of the way to the next Fajr.
+func (pc *PrayerCalculator) CalculateSunTimes(
+	latitude, longitude float64,
+	date time.Time,
+	method models.PrayerCalculationMethod,
+	timezoneOffset float64,
+) models.SunTimes {
+	params := pc.getCalculationParams(method)
+	dhuhrUTC, declination := pc.solarNoon(longitude, date)
+	sunriseUTC := dhuhrUTC - pc.hourAngleForAngle(latitude, declination, 0.833)/15.0
+	sunsetUTC := dhuhrUTC + pc.hourAngleForAngle(latitude, declination, 0.833)/15.0
+
+	times := models.SunTimes{
+		Sunrise:   pc.toTimeString(date, sunriseUTC, timezoneOffset),
+		SolarNoon: pc.toTimeString(date, dhuhrUTC, timezoneOffset),
+		Sunset:    pc.toTimeString(date, sunsetUTC, timezoneOffset),
+	}
+
+	next := date.AddDate(0, 0, 1)
+	nextDhuhrUTC, nextDeclination := pc.solarNoon(longitude, next)
+	nextSunriseUTC := nextDhuhrUTC - pc.hourAngleForAngle(latitude, nextDeclination, 0.833)/15.0
+	nextFajrUTC := nextDhuhrUTC - pc.hourAngleForAngle(latitude, nextDeclination, params.FajrAngle)/15.0
+
+	sunset, ok := pc.toTime(date, sunsetUTC, timezoneOffset)
+	if !ok {
+		return times
+	}
+	if nextSunrise, ok := pc.toTime(next, nextSunriseUTC, timezoneOffset); ok {
+		times.Midnight = sunset.Add(nextSunrise.Sub(sunset) / 2).Truncate(time.Minute).Format(time.RFC3339)
+	}
+	if nextFajr, ok := pc.toTime(next, nextFajrUTC, timezoneOffset); ok {
+		times.LastThird = sunset.Add(nextFajr.Sub(sunset) * 2 / 3).Truncate(time.Minute).Format(time.RFC3339)
+	}
+
+	return times
+}
+
+// CalculateForbiddenWindows computes the times of a day in which voluntary
+// prayer is disliked: after sunrise, before solar noon and before sunset,
+// each lasting the configured number of minutes.
+func (pc *PrayerCalculator) CalculateForbiddenWindows(
+	latitude, longitude float64,
+	date time.Time,
+	durations models.ForbiddenWindowSettings,
+	timezoneOffset float64,
+) []models.ForbiddenWindow {
+	dhuhrUTC, declination := pc.solarNoon(longitude, date)
+	sunriseUTC := dhuhrUTC - pc.hourAngleForAngle(latitude, declination, 0.833)/15.0
+	sunsetUTC := dhuhrUTC + pc.hourAngleForAngle(latitude, declination, 0.833)/15.0
+
+	windows := make([]models.ForbiddenWindow, 0, 3)
+	add := func(kind models.ForbiddenKind, startUTC, endUTC float64) {
+		if endUTC <= startUTC {
+			return
+		}
+		start, ok := pc.toTime(date, startUTC, timezoneOffset)
+		if !ok {
+			return
+		}
+		end, ok := pc.toTime(date, endUTC, timezoneOffset)
+		if !ok {
+			return
+		}
+		windows = append(windows, models.ForbiddenWindow{
+			Kind:  kind,
+			Start: start.Format(time.RFC3339),
+			End:   end.Format(time.RFC3339),
+		})
+	}
+
+	add(models.ForbiddenSunrise, sunriseUTC, sunriseUTC+float64(durations.SunriseMinutes)/60.0)
+	add(models.ForbiddenZenith, dhuhrUTC-float64(durations.ZenithMinutes)/60.0, dhuhrUTC)
+	add(models.ForbiddenSunset, sunsetUTC-float64(durations.SunsetMinutes)/60.0, sunsetUTC)
+
+	return windows
+}
+
+// solarNoon returns the time of solar noon in UTC decimal hours and the
+// solar declination in degrees for a given longitude and date.
+func (pc *PrayerCalculator) solarNoon(longitude float64, date time.Time) (float64, float64) {
+	// Calculate Julian date
+	jd := pc.calculateJulianDate(date)
+
+	// Days since J2000.0
+	d := jd - 2451545.0
+
+	// Calculate equation of time and solar declination
+	eqTime := pc.calculateEquationOfTime(d)
+	declination := pc.calculateSolarDeclination(d)
+
+	// Calculate Dhuhr first (solar noon) - calculate in UTC then we'll convert
+	// Dhuhr in UTC = 12:00 - longitude/15 - eqTime/60
+	return 12.0 - longitude/15.0 - eqTime/60.0, declination
+}
+
 // getCalculationParams returns the calculation parameters for a given method.
 func (pc *PrayerCalculator) getCalculationParams(method models.PrayerCalculationMethod) CalculationParams {
 	switch method {
@@ -226,9 +311,18 @@ func (pc *PrayerCalculator) calculateIsha(latitude, declination float64, params
 
 // toTimeString converts UTC decimal hours to an RFC3339 time string in local timezone.
 func (pc *PrayerCalculator) toTimeString(date time.Time, hoursUTC float64, timezoneOffset float64) string {
-	if math.IsNaN(hoursUTC) {
+	t, ok := pc.toTime(date, hoursUTC, timezoneOffset)
+	if !ok {
 		return ""
 	}
+	return t.Format(time.RFC3339)
+}
+
+// toTime converts UTC decimal hours to a time on the given date in local timezone.
+func (pc *PrayerCalculator) toTime(date time.Time, hoursUTC float64, timezoneOffset float64) (time.Time, bool) {
+	if math.IsNaN(hoursUTC) {
+		return time.Time{}, false
+	}
 
 	// Add timezone offset to convert from UTC to local time
 	hoursLocal := hoursUTC + timezoneOffset
@@ -241,8 +335,7 @@ func (pc *PrayerCalculator) toTimeString(date time.Time, hoursUTC float64, timez
 	offsetSeconds := int(timezoneOffset * 3600)
 	loc := time.FixedZone("Local", offsetSeconds)
 
-	t := time.Date(date.Year(), date.Month(), date.Day(), h, m, 0, 0, loc)
-	return t.Format(time.RFC3339)
+	return time.Date(date.Year(), date.Month(), date.Day(), h, m, 0, 0, loc), true
 }
 
 // Utility functions
@@ -0,0 +1,375 @@
+// Package services contains business logic for the AzanAlarm application.
+package services
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"AzanAlarm/internal/models"
+)
+
+// dateLayout is the layout used for calendar dates in storage and bindings.
+const dateLayout = "2006-01-02"
+
+// PrayerLogService tracks whether each prayer was performed. It is safe
+// for concurrent use.
+type PrayerLogService struct {
+	storage *StorageService
+	mu      sync.RWMutex
+	entries map[string]map[models.Prayer]models.PrayerLogEntry // keyed by date
+}
+
+// NewPrayerLogService creates a new PrayerLogService instance.
+func NewPrayerLogService(storage *StorageService) *PrayerLogService {
+	ps := &PrayerLogService{
+		storage: storage,
+		entries: make(map[string]map[models.Prayer]models.PrayerLogEntry),
+	}
+
+	// Load existing log
+	var savedEntries []models.PrayerLogEntry
+	if err := storage.Load("prayer_log", &savedEntries); err == nil {
+		for _, e := range savedEntries {
+			ps.put(e)
+		}
+	}
+
+	return ps
+}
+
+// Reload replaces the log with the one in storage, e.g. after the file was
+// replaced outside the app. If any entry is invalid, the file is rejected
+// and the current log is kept.
+func (ps *PrayerLogService) Reload() error {
+	var savedEntries []models.PrayerLogEntry
+	if err := ps.storage.Load("prayer_log", &savedEntries); err != nil {
+		return err
+	}
+	for _, e := range savedEntries {
+		if _, err := time.Parse(dateLayout, e.Date); err != nil {
+			return models.ValidationError("invalid date %q", e.Date)
+		}
+		if !isKnownPrayer(e.Prayer) {
+			return models.ValidationError("unknown prayer %q", e.Prayer)
+		}
+		if !e.Status.IsValid() {
+			return models.ValidationError("unknown prayer status %q", e.Status)
+		}
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.entries = make(map[string]map[models.Prayer]models.PrayerLogEntry)
+	for _, e := range savedEntries {
+		ps.put(e)
+	}
+	return nil
+}
+
+// LogPrayer records the status of a prayer on a given date, replacing any
+// earlier record for the same date and prayer.
+func (ps *PrayerLogService) LogPrayer(date string, prayer models.Prayer, status models.PrayerStatus) (models.PrayerLogEntry, error) {
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		return models.PrayerLogEntry{}, models.ValidationError("invalid date %q", date)
+	}
+	if !isKnownPrayer(prayer) {
+		return models.PrayerLogEntry{}, models.ValidationError("unknown prayer %q", prayer)
+	}
+	if !status.IsValid() {
+		return models.PrayerLogEntry{}, models.ValidationError("unknown prayer status %q", status)
+	}
+
+	entry := models.PrayerLogEntry{
+		Date:      date,
+		Prayer:    prayer,
+		Status:    status,
+		UpdatedAt: time.Now().UnixMilli(),
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.put(entry)
+	if err := ps.save(); err != nil {
+		return models.PrayerLogEntry{}, err
+	}
+	return entry, nil
+}
+
+// ClearPrayer removes the record for a prayer on a given date.
+func (ps *PrayerLogService) ClearPrayer(date string, prayer models.Prayer) error {
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		return models.ValidationError("invalid date %q", date)
+	}
+	if !isKnownPrayer(prayer) {
+		return models.ValidationError("unknown prayer %q", prayer)
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	day, ok := ps.entries[date]
+	if !ok {
+		return nil
+	}
+	delete(day, prayer)
+	if len(day) == 0 {
+		delete(ps.entries, date)
+	}
+	return ps.save()
+}
+
+// stageImport computes the prayer log after restoring a backup, without
+// changing anything until the returned step is committed. In merge mode,
+// an entry replaces an existing one for the same date and prayer only if
+// it was updated more recently.
+func (ps *PrayerLogService) stageImport(entries []models.PrayerLogEntry, mode models.RestoreMode) (restoreStep, error) {
+	counts := models.RestoreCounts{Section: "prayerLog"}
+	for _, e := range entries {
+		if _, err := time.Parse(dateLayout, e.Date); err != nil {
+			return restoreStep{}, models.ValidationError("invalid date %q", e.Date)
+		}
+		if !isKnownPrayer(e.Prayer) {
+			return restoreStep{}, models.ValidationError("unknown prayer %q", e.Prayer)
+		}
+		if !e.Status.IsValid() {
+			return restoreStep{}, models.ValidationError("unknown prayer status %q", e.Status)
+		}
+	}
+
+	// The restored log is built in a copy of the service
+	ps.mu.RLock()
+	staged := &PrayerLogService{entries: make(map[string]map[models.Prayer]models.PrayerLogEntry)}
+	if mode == models.RestoreReplace {
+		counts.Removed = len(ps.entriesBetween("", ""))
+	} else {
+		for _, day := range ps.entries {
+			for _, e := range day {
+				staged.put(e)
+			}
+		}
+	}
+	ps.mu.RUnlock()
+
+	for _, e := range entries {
+		existing, ok := staged.entries[e.Date][e.Prayer]
+		switch {
+		case !ok:
+			counts.Added++
+		case e.UpdatedAt > existing.UpdatedAt:
+			counts.Updated++
+		default:
+			counts.Unchanged++
+			continue
+		}
+		staged.put(e)
+	}
+
+	var previous map[string]map[models.Prayer]models.PrayerLogEntry
+	return restoreStep{
+		counts: counts,
+		commit: func() error {
+			ps.mu.Lock()
+			defer ps.mu.Unlock()
+
+			previous = ps.entries
+			ps.entries = staged.entries
+			if err := ps.save(); err != nil {
+				ps.entries = previous
+				return err
+			}
+			return nil
+		},
+		undo: func() error {
+			ps.mu.Lock()
+			defer ps.mu.Unlock()
+
+			ps.entries = previous
+			return ps.save()
+		},
+	}, nil
+}
+
+// GetEntries returns the records between two inclusive dates, sorted by
+// date and prayer order. Empty bounds are open-ended.
+func (ps *PrayerLogService) GetEntries(from, to string) []models.PrayerLogEntry {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.entriesBetween(from, to)
+}
+
+// entriesBetween returns the records between two inclusive dates. The
+// caller must hold the lock.
+func (ps *PrayerLogService) entriesBetween(from, to string) []models.PrayerLogEntry {
+	result := make([]models.PrayerLogEntry, 0)
+	for _, date := range ps.sortedDates() {
+		if from != "" && date < from {
+			continue
+		}
+		if to != "" && date > to {
+			continue
+		}
+		for _, prayer := range models.AllPrayers() {
+			if e, ok := ps.entries[date][prayer]; ok {
+				result = append(result, e)
+			}
+		}
+	}
+	return result
+}
+
+// GetStats computes streaks and per-prayer statistics over the window
+// ending on the given day.
+func (ps *PrayerLogService) GetStats(window models.StatsWindow, today time.Time) (models.PrayerStats, error) {
+	days := window.Days()
+	if days == 0 {
+		return models.PrayerStats{}, models.ValidationError("unknown stats window %q", window)
+	}
+
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	to := today.Format(dateLayout)
+	from := today.AddDate(0, 0, -(days - 1)).Format(dateLayout)
+
+	stats := models.PrayerStats{
+		Window:  window,
+		From:    from,
+		To:      to,
+		Prayers: make([]models.PrayerStat, 0, 5),
+	}
+
+	for _, prayer := range models.AllPrayers() {
+		stat := models.PrayerStat{Prayer: prayer}
+		onTime := 0
+		for date, day := range ps.entries {
+			if date < from || date > to {
+				continue
+			}
+			e, ok := day[prayer]
+			if !ok {
+				continue
+			}
+			stat.Logged++
+			if e.Status.IsOnTime() {
+				onTime++
+			}
+			switch e.Status {
+			case models.PrayedOnTime:
+				stat.OnTime++
+			case models.PrayedLate:
+				stat.Late++
+			case models.PrayedCongregation:
+				stat.Congregation++
+			case models.PrayerMissed:
+				stat.Missed++
+			}
+		}
+		if stat.Logged > 0 {
+			percent := float64(onTime) / float64(stat.Logged) * 100
+			stat.OnTimePercent = math.Round(percent*10) / 10
+		}
+		stats.Prayers = append(stats.Prayers, stat)
+	}
+
+	stats.CurrentStreak = ps.currentStreak(today)
+	stats.LongestStreak = ps.longestStreak()
+
+	return stats, nil
+}
+
+// currentStreak counts consecutive complete days ending today. An
+// incomplete today does not break the streak, it just is not counted yet.
+func (ps *PrayerLogService) currentStreak(today time.Time) int {
+	day := today
+	if !ps.isCompleteDay(day.Format(dateLayout)) {
+		day = day.AddDate(0, 0, -1)
+	}
+
+	streak := 0
+	for ps.isCompleteDay(day.Format(dateLayout)) {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return streak
+}
+
+// longestStreak returns the longest run of consecutive complete days.
+func (ps *PrayerLogService) longestStreak() int {
+	longest, current := 0, 0
+	var previous time.Time
+	for _, date := range ps.sortedDates() {
+		if !ps.isCompleteDay(date) {
+			current = 0
+			continue
+		}
+		d, err := time.Parse(dateLayout, date)
+		if err != nil {
+			continue
+		}
+		if current > 0 && d.Sub(previous) == 24*time.Hour {
+			current++
+		} else {
+			current = 1
+		}
+		previous = d
+		if current > longest {
+			longest = current
+		}
+	}
+	return longest
+}
+
+// isCompleteDay checks if all five prayers were performed on the date.
+func (ps *PrayerLogService) isCompleteDay(date string) bool {
+	day, ok := ps.entries[date]
+	if !ok {
+		return false
+	}
+	for _, prayer := range models.AllPrayers() {
+		e, ok := day[prayer]
+		if !ok || !e.Status.IsPerformed() {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedDates returns the logged dates in ascending order.
+func (ps *PrayerLogService) sortedDates() []string {
+	dates := make([]string, 0, len(ps.entries))
+	for date := range ps.entries {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	return dates
+}
+
+// put stores an entry in the in-memory index. The caller must hold the lock.
+func (ps *PrayerLogService) put(entry models.PrayerLogEntry) {
+	day, ok := ps.entries[entry.Date]
+	if !ok {
+		day = make(map[models.Prayer]models.PrayerLogEntry)
+		ps.entries[entry.Date] = day
+	}
+	day[entry.Prayer] = entry
+}
+
+// save persists the prayer log to storage. The caller must hold the lock.
+func (ps *PrayerLogService) save() error {
+	return ps.storage.Save("prayer_log", ps.entriesBetween("", ""))
+}
+
+// isKnownPrayer checks if the prayer is one of the five daily prayers.
+func isKnownPrayer(prayer models.Prayer) bool {
+	for _, p := range models.AllPrayers() {
+		if p == prayer {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,471 @@
+// Package services contains business logic for the AzanAlarm application.
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"AzanAlarm/internal/models"
+)
+
+// profileAutoState is what automatic activation remembers across restarts.
+type profileAutoState struct {
+	LastAutoID int `json:"lastAutoId"` // 0 when no profile matched
+}
+
+// ProfileService manages named groups of alarms that are switched together.
+// It is safe for concurrent use.
+type ProfileService struct {
+	storage   *StorageService
+	alarms    *AlarmService
+	locations *LocationService
+	mu        sync.Mutex
+	profiles  []models.AlarmProfile
+	nextID    int
+
+	// lastAutoID is the profile picked by the last automatic evaluation, so
+	// a manual choice is not overridden until the matching profile changes.
+	// It is saved, so a restart does not override the choice either.
+	lastAutoID int
+}
+
+// NewProfileService creates a new ProfileService instance.
+func NewProfileService(storage *StorageService, alarms *AlarmService, locations *LocationService) *ProfileService {
+	ps := &ProfileService{
+		storage:    storage,
+		alarms:     alarms,
+		locations:  locations,
+		profiles:   []models.AlarmProfile{},
+		nextID:     1,
+		lastAutoID: -1,
+	}
+
+	// Load existing profiles
+	var savedProfiles []models.AlarmProfile
+	if err := storage.Load("alarm_profiles", &savedProfiles); err == nil {
+		ps.profiles = savedProfiles
+		for _, p := range ps.profiles {
+			if p.ID >= ps.nextID {
+				ps.nextID = p.ID + 1
+			}
+		}
+	}
+	var auto profileAutoState
+	if err := storage.Load("profile_auto", &auto); err == nil {
+		ps.lastAutoID = auto.LastAutoID
+	}
+
+	return ps
+}
+
+// Reload replaces the profiles with those in storage, e.g. after the file
+// was replaced outside the app.
+func (ps *ProfileService) Reload() error {
+	var profiles []models.AlarmProfile
+	if err := ps.storage.Load("alarm_profiles", &profiles); err != nil {
+		return err
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.profiles = profiles
+	for _, p := range ps.profiles {
+		if p.ID >= ps.nextID {
+			ps.nextID = p.ID + 1
+		}
+	}
+	return nil
+}
+
+// ReloadAutoState replaces the record of the last automatically activated
+// profile with the one in storage.
+func (ps *ProfileService) ReloadAutoState() error {
+	var auto profileAutoState
+	if err := ps.storage.Load("profile_auto", &auto); err != nil {
+		return err
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.lastAutoID = auto.LastAutoID
+	return nil
+}
+
+// GetProfiles returns a copy of all profiles.
+func (ps *ProfileService) GetProfiles() []models.AlarmProfile {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	result := make([]models.AlarmProfile, len(ps.profiles))
+	for i, p := range ps.profiles {
+		result[i] = p.Clone()
+	}
+	return result
+}
+
+// GetProfile returns a copy of a specific profile by ID.
+func (ps *ProfileService) GetProfile(id int) *models.AlarmProfile {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if existing := ps.find(id); existing != nil {
+		profile := existing.Clone()
+		return &profile
+	}
+	return nil
+}
+
+// CreateProfile creates a new profile.
+func (ps *ProfileService) CreateProfile(profile models.AlarmProfile) (models.AlarmProfile, error) {
+	profile.Name = strings.TrimSpace(profile.Name)
+	if profile.AlarmIDs == nil {
+		profile.AlarmIDs = []int{}
+	}
+	profile = profile.Clone()
+	if err := ps.validate(profile); err != nil {
+		return models.AlarmProfile{}, err
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	profile.ID = ps.nextID
+	ps.nextID++
+	profile.IsActive = false
+	now := time.Now().UnixMilli()
+	profile.CreatedAt = now
+	profile.UpdatedAt = now
+
+	ps.profiles = append(ps.profiles, profile)
+	if err := ps.save(); err != nil {
+		return models.AlarmProfile{}, err
+	}
+	return profile.Clone(), nil
+}
+
+// UpdateProfile updates the name, members and trigger of an existing profile.
+func (ps *ProfileService) UpdateProfile(profile models.AlarmProfile) error {
+	profile.Name = strings.TrimSpace(profile.Name)
+	if profile.AlarmIDs == nil {
+		profile.AlarmIDs = []int{}
+	}
+	profile = profile.Clone()
+	if err := ps.validate(profile); err != nil {
+		return err
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	existing := ps.find(profile.ID)
+	if existing == nil {
+		return models.NotFoundError("profile %d not found", profile.ID)
+	}
+	profile.IsActive = existing.IsActive
+	profile.CreatedAt = existing.CreatedAt
+	profile.UpdatedAt = time.Now().UnixMilli()
+	*existing = profile
+	return ps.save()
+}
+
+// DeleteProfile removes a profile. Its alarms keep their current state.
+func (ps *ProfileService) DeleteProfile(id int) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if ps.find(id) == nil {
+		return models.NotFoundError("profile %d not found", id)
+	}
+	newProfiles := make([]models.AlarmProfile, 0, len(ps.profiles))
+	for _, p := range ps.profiles {
+		if p.ID != id {
+			newProfiles = append(newProfiles, p)
+		}
+	}
+	ps.profiles = newProfiles
+	return ps.save()
+}
+
+// RemoveAlarm drops a deleted alarm from every profile.
+func (ps *ProfileService) RemoveAlarm(alarmID int) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	changed := false
+	for i := range ps.profiles {
+		if !ps.profiles[i].HasAlarm(alarmID) {
+			continue
+		}
+		ids := make([]int, 0, len(ps.profiles[i].AlarmIDs))
+		for _, id := range ps.profiles[i].AlarmIDs {
+			if id != alarmID {
+				ids = append(ids, id)
+			}
+		}
+		ps.profiles[i].AlarmIDs = ids
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return ps.save()
+}
+
+// ActivateProfile switches on the alarms of a profile and switches off the
+// alarms that only belong to other profiles, in a single save. Alarms that
+// are in no profile are left untouched.
+func (ps *ProfileService) ActivateProfile(id int) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	return ps.activate(id)
+}
+
+// activate switches to a profile. The caller must hold the lock.
+func (ps *ProfileService) activate(id int) error {
+	profile := ps.find(id)
+	if profile == nil {
+		return models.NotFoundError("profile %d not found", id)
+	}
+
+	states := make(map[int]bool)
+	for _, p := range ps.profiles {
+		for _, alarmID := range p.AlarmIDs {
+			if _, ok := states[alarmID]; !ok {
+				states[alarmID] = false
+			}
+		}
+	}
+	for _, alarmID := range profile.AlarmIDs {
+		states[alarmID] = true
+	}
+
+	// Remember the current states, so the alarms can be switched back if
+	// the profiles cannot be saved
+	previousStates := make(map[int]bool, len(states))
+	for alarmID := range states {
+		if alarm := ps.alarms.GetAlarm(alarmID); alarm != nil {
+			previousStates[alarmID] = alarm.IsActive
+		}
+	}
+	if err := ps.alarms.SetActiveStates(states); err != nil {
+		return err
+	}
+
+	previous := make([]bool, len(ps.profiles))
+	for i := range ps.profiles {
+		previous[i] = ps.profiles[i].IsActive
+		ps.profiles[i].IsActive = ps.profiles[i].ID == id
+	}
+	if err := ps.save(); err != nil {
+		for i := range ps.profiles {
+			ps.profiles[i].IsActive = previous[i]
+		}
+		if undoErr := ps.alarms.SetActiveStates(previousStates); undoErr != nil {
+			return models.StorageError(fmt.Errorf("%w, and the alarms could not be switched back: %v", err, undoErr))
+		}
+		return err
+	}
+	return nil
+}
+
+// ApplyAutomatic activates the profile whose trigger matches the given date
+// and current location. When several match, the one with the most
+// conditions wins, then the lowest ID. It returns the activated profile, or
+// nil if nothing changed.
+func (ps *ProfileService) ApplyAutomatic(date time.Time) (*models.AlarmProfile, error) {
+	current := ps.locations.GetCurrentLocation()
+	saved := ps.locations.GetSavedLocations()
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	var best *models.AlarmProfile
+	for i := range ps.profiles {
+		p := &ps.profiles[i]
+		var savedLocation *models.Location
+		if p.AutoActivate.LocationID != 0 {
+			for j := range saved {
+				if saved[j].ID == p.AutoActivate.LocationID {
+					savedLocation = &saved[j]
+					break
+				}
+			}
+		}
+		if !p.AutoActivate.Matches(date, savedLocation, current) {
+			continue
+		}
+		if best == nil || p.AutoActivate.Specificity() > best.AutoActivate.Specificity() {
+			best = p
+		}
+	}
+
+	bestID := 0
+	if best != nil {
+		bestID = best.ID
+	}
+	if bestID == ps.lastAutoID {
+		return nil, nil
+	}
+	if err := ps.storage.Save("profile_auto", profileAutoState{LastAutoID: bestID}); err != nil {
+		return nil, err
+	}
+	ps.lastAutoID = bestID
+	if best == nil || best.IsActive {
+		return nil, nil
+	}
+
+	if err := ps.activate(best.ID); err != nil {
+		return nil, err
+	}
+	activated := ps.find(bestID).Clone()
+	return &activated, nil
+}
+
+// stageImport computes the profiles after restoring a backup, without
+// changing anything until the returned step is committed. Alarms and saved
+// locations are pointed at the restored ones through alarmIDs and
+// locationIDs; members that were not restored are dropped. On replace, the
+// current profiles go as well, since they refer to the alarms being
+// replaced. In merge mode, profiles with the name of an existing one are
+// skipped and the rest get new IDs and start inactive.
+func (ps *ProfileService) stageImport(profiles []models.AlarmProfile, alarmIDs, locationIDs map[int]int, mode models.RestoreMode) (restoreStep, error) {
+	counts := models.RestoreCounts{Section: "alarmProfiles"}
+
+	imported := make([]models.AlarmProfile, 0, len(profiles))
+	for _, profile := range profiles {
+		members := profile.AlarmIDs
+		profile.AlarmIDs = make([]int, 0, len(members))
+		for _, id := range members {
+			if newID, ok := alarmIDs[id]; ok && !profile.HasAlarm(newID) {
+				profile.AlarmIDs = append(profile.AlarmIDs, newID)
+			}
+		}
+		if id := profile.AutoActivate.LocationID; id != 0 {
+			profile.AutoActivate.LocationID = locationIDs[id]
+		}
+		if err := profile.Validate(); err != nil {
+			return restoreStep{}, err
+		}
+		imported = append(imported, profile)
+	}
+
+	// The restored profiles are built in a copy of the service
+	ps.mu.Lock()
+	staged := &ProfileService{nextID: ps.nextID}
+	if mode == models.RestoreReplace {
+		counts.Removed = len(ps.profiles)
+		staged.profiles = make([]models.AlarmProfile, 0, len(imported))
+		staged.nextID = 1
+		for _, p := range imported {
+			if p.ID >= staged.nextID {
+				staged.nextID = p.ID + 1
+			}
+		}
+	} else {
+		staged.profiles = append(make([]models.AlarmProfile, 0, len(ps.profiles)+len(imported)), ps.profiles...)
+	}
+	ps.mu.Unlock()
+
+	for _, profile := range imported {
+		if mode == models.RestoreMerge {
+			if staged.findByName(profile.Name) != nil {
+				counts.Unchanged++
+				continue
+			}
+			// The merged alarms keep their own on/off state
+			profile.IsActive = false
+		}
+		// Backup IDs are kept on replace, unless they clash
+		if mode == models.RestoreMerge || profile.ID <= 0 || staged.find(profile.ID) != nil {
+			profile.ID = staged.nextID
+			staged.nextID++
+		}
+		staged.profiles = append(staged.profiles, profile)
+		counts.Added++
+	}
+
+	var previous []models.AlarmProfile
+	var previousNextID int
+	return restoreStep{
+		counts: counts,
+		commit: func() error {
+			ps.mu.Lock()
+			defer ps.mu.Unlock()
+
+			previous, previousNextID = ps.profiles, ps.nextID
+			ps.profiles, ps.nextID = staged.profiles, staged.nextID
+			if err := ps.save(); err != nil {
+				ps.profiles, ps.nextID = previous, previousNextID
+				return err
+			}
+			ps.lastAutoID = -1
+			return nil
+		},
+		undo: func() error {
+			ps.mu.Lock()
+			defer ps.mu.Unlock()
+
+			ps.profiles, ps.nextID = previous, previousNextID
+			return ps.save()
+		},
+	}, nil
+}
+
+// validate checks a profile and that the alarms and saved location it
+// refers to exist, so it does not fail later when it is activated.
+func (ps *ProfileService) validate(profile models.AlarmProfile) error {
+	var errs models.ValidationErrors
+	if err := profile.Validate(); err != nil {
+		errs = append(errs, models.AsAppError(err).Fields...)
+	}
+	for _, id := range profile.AlarmIDs {
+		if ps.alarms.GetAlarm(id) == nil {
+			errs.Add("alarmIds", "alarm %d not found", id)
+		}
+	}
+	if id := profile.AutoActivate.LocationID; id > 0 {
+		found := false
+		for _, location := range ps.locations.GetSavedLocations() {
+			if location.ID == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs.Add("autoActivate.locationId", "saved location %d not found", id)
+		}
+	}
+	return errs.Err()
+}
+
+// find returns the stored profile with the ID, or nil. The caller must
+// hold the lock.
+func (ps *ProfileService) find(id int) *models.AlarmProfile {
+	for i := range ps.profiles {
+		if ps.profiles[i].ID == id {
+			return &ps.profiles[i]
+		}
+	}
+	return nil
+}
+
+// findByName returns the stored profile with the name, ignoring case, or
+// nil. The caller must hold the lock.
+func (ps *ProfileService) findByName(name string) *models.AlarmProfile {
+	for i := range ps.profiles {
+		if strings.EqualFold(ps.profiles[i].Name, name) {
+			return &ps.profiles[i]
+		}
+	}
+	return nil
+}
+
+// save persists profiles to storage. The caller must hold the lock.
+func (ps *ProfileService) save() error {
+	return ps.storage.Save("alarm_profiles", ps.profiles)
+}
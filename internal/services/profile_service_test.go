@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"AzanAlarm/internal/models"
+)
+
+// createTestProfiles creates an automatic "Work" profile matching every
+// day and a manual "Home" profile, each holding one alarm.
+func createTestProfiles(t *testing.T, c *Core) (work, home models.AlarmProfile) {
+	t.Helper()
+	alarms := createLabelledAlarms(t, c, "work", "home")
+	work, err := c.Profiles.CreateProfile(models.AlarmProfile{
+		Name:         "Work",
+		AlarmIDs:     []int{alarms[0].ID},
+		AutoActivate: models.ProfileTrigger{Weekdays: []int{1, 2, 3, 4, 5, 6, 7}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	home, err = c.Profiles.CreateProfile(models.AlarmProfile{Name: "Home", AlarmIDs: []int{alarms[1].ID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return work, home
+}
+
+func TestManualProfileSurvivesRestart(t *testing.T) {
+	c := newTestCore(t, nil)
+	work, home := createTestProfiles(t, c)
+	now := time.Now()
+
+	activated, err := c.Profiles.ApplyAutomatic(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if activated == nil || activated.ID != work.ID {
+		t.Fatalf("got %+v, want the Work profile activated", activated)
+	}
+	if err := c.Profiles.ActivateProfile(home.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := NewCore(c.Storage)
+	if activated, err := restarted.Profiles.ApplyAutomatic(now.Add(time.Minute)); err != nil || activated != nil {
+		t.Fatalf("got %+v, %v after a restart, want the manual choice kept", activated, err)
+	}
+	if p := restarted.Profiles.GetProfile(home.ID); p == nil || !p.IsActive {
+		t.Errorf("the Home profile is no longer active: %+v", p)
+	}
+}
+
+// TestActivateProfileRollsBackAlarms activates a profile while storage
+// refuses to save the profiles, after the alarms were already switched.
+func TestActivateProfileRollsBackAlarms(t *testing.T) {
+	c := newTestCore(t, nil)
+	work, _ := createTestProfiles(t, c)
+	before := c.Alarms.GetAlarms()
+
+	// Make the profiles unreadable, so saving them is refused
+	backend := c.Storage.backend
+	if err := backend.Write("alarm_profiles", []byte(`[{"id":`)); err != nil {
+		t.Fatal(err)
+	}
+	var profiles []models.AlarmProfile
+	if err := c.Storage.Load("alarm_profiles", &profiles); err == nil {
+		t.Fatal("damaged profiles loaded")
+	}
+
+	if err := c.Profiles.ActivateProfile(work.ID); !errors.Is(err, models.ErrStorageUnavailable) {
+		t.Fatalf("got %v, want a storage error", err)
+	}
+	for _, alarm := range c.Alarms.GetAlarms() {
+		for _, b := range before {
+			if b.ID == alarm.ID && b.IsActive != alarm.IsActive {
+				t.Errorf("alarm %q was left switched %v", alarm.Label, alarm.IsActive)
+			}
+		}
+	}
+	for _, p := range c.Profiles.GetProfiles() {
+		if p.IsActive {
+			t.Errorf("profile %q was left active", p.Name)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+// Package services contains business logic for the AzanAlarm application.
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"AzanAlarm/internal/models"
+)
+
+// QadaService manages the ledger of makeup prayers. It is safe for
+// concurrent use.
+type QadaService struct {
+	storage   *StorageService
+	prayerLog *PrayerLogService
+	mu        sync.RWMutex
+	ledger    models.QadaLedger
+}
+
+// NewQadaService creates a new QadaService instance.
+func NewQadaService(storage *StorageService, prayerLog *PrayerLogService) *QadaService {
+	qs := &QadaService{
+		storage:   storage,
+		prayerLog: prayerLog,
+		ledger:    models.NewQadaLedger(),
+	}
+
+	// Load existing ledger
+	var savedLedger models.QadaLedger
+	if err := storage.Load("qada", &savedLedger); err == nil {
+		for prayer, count := range savedLedger.Outstanding {
+			qs.ledger.Outstanding[prayer] = count
+		}
+		for prayer, count := range savedLedger.Completed {
+			qs.ledger.Completed[prayer] = count
+		}
+		if savedLedger.DailyGoal > 0 {
+			qs.ledger.DailyGoal = savedLedger.DailyGoal
+		}
+		if savedLedger.SyncedMissed != nil {
+			qs.ledger.SyncedMissed = savedLedger.SyncedMissed
+		}
+		qs.ledger.UpdatedAt = savedLedger.UpdatedAt
+	}
+
+	return qs
+}
+
+// Reload replaces the ledger with the one in storage, e.g. after the file
+// was replaced outside the app.
+func (qs *QadaService) Reload() error {
+	var savedLedger models.QadaLedger
+	if err := qs.storage.Load("qada", &savedLedger); err != nil {
+		return err
+	}
+	ledger, err := restorableLedger(savedLedger)
+	if err != nil {
+		return err
+	}
+
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+
+	qs.ledger = ledger
+	return nil
+}
+
+// GetLedger returns a copy of the current ledger.
+func (qs *QadaService) GetLedger() models.QadaLedger {
+	qs.mu.RLock()
+	defer qs.mu.RUnlock()
+
+	return qs.ledger.Clone()
+}
+
+// AddOutstanding adds owed makeup prayers for a single prayer.
+func (qs *QadaService) AddOutstanding(prayer models.Prayer, count int) error {
+	if !isKnownPrayer(prayer) {
+		return models.ValidationError("unknown prayer %q", prayer)
+	}
+	if count <= 0 {
+		return models.ValidationError("count must be positive, got %d", count)
+	}
+
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+
+	qs.ledger.Outstanding[prayer] += count
+	return qs.save()
+}
+
+// AddPeriod adds one owed makeup of every prayer for each day in a period
+// of years, months and days counted back from today, e.g. "2 years".
+// It returns the number of days added.
+func (qs *QadaService) AddPeriod(years, months, days int, today time.Time) (int, error) {
+	if years < 0 || months < 0 || days < 0 {
+		return 0, models.ValidationError("period must not be negative")
+	}
+	start := today.AddDate(-years, -months, -days)
+	total := int(startOfDay(today).Sub(startOfDay(start)).Hours()/24 + 0.5)
+	if total == 0 {
+		return 0, models.ValidationError("period must not be empty")
+	}
+
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+
+	for _, prayer := range models.AllPrayers() {
+		qs.ledger.Outstanding[prayer] += total
+	}
+	return total, qs.save()
+}
+
+// RecordMakeup records completed makeup prayers, reducing what is owed.
+func (qs *QadaService) RecordMakeup(prayer models.Prayer, count int) error {
+	if !isKnownPrayer(prayer) {
+		return models.ValidationError("unknown prayer %q", prayer)
+	}
+	if count <= 0 {
+		return models.ValidationError("count must be positive, got %d", count)
+	}
+
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+
+	if count > qs.ledger.Outstanding[prayer] {
+		return models.ValidationError("only %d %s makeup prayers are outstanding", qs.ledger.Outstanding[prayer], prayer.DisplayName())
+	}
+	qs.ledger.Outstanding[prayer] -= count
+	qs.ledger.Completed[prayer] += count
+	return qs.save()
+}
+
+// SetDailyGoal sets how many makeups of each prayer are planned per day.
+func (qs *QadaService) SetDailyGoal(goal int) error {
+	if goal <= 0 {
+		return models.ValidationError("daily goal must be positive, got %d", goal)
+	}
+
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+
+	qs.ledger.DailyGoal = goal
+	return qs.save()
+}
+
+// stageRestore returns the step that replaces the ledger with one from a
+// backup.
+func (qs *QadaService) stageRestore(ledger models.QadaLedger) (restoreStep, error) {
+	restored, err := restorableLedger(ledger)
+	if err != nil {
+		return restoreStep{}, err
+	}
+
+	var previous models.QadaLedger
+	return restoreStep{
+		counts: models.RestoreCounts{Section: "qada", Updated: 1},
+		commit: func() error {
+			qs.mu.Lock()
+			defer qs.mu.Unlock()
+
+			previous = qs.ledger
+			qs.ledger = restored
+			if err := qs.save(); err != nil {
+				qs.ledger = previous
+				return err
+			}
+			return nil
+		},
+		undo: func() error {
+			qs.mu.Lock()
+			defer qs.mu.Unlock()
+
+			qs.ledger = previous
+			return qs.save()
+		},
+	}, nil
+}
+
+// SyncFromPrayerLog counts missed prayers from the prayer log that have not
+// been counted yet. Entries that were counted but are no longer marked as
+// missed are taken off the ledger again. It returns the net change.
+func (qs *QadaService) SyncFromPrayerLog() (int, error) {
+	missed := make(map[string]models.Prayer)
+	for _, e := range qs.prayerLog.GetEntries("", "") {
+		if e.Status == models.PrayerMissed {
+			missed[e.Date+"/"+string(e.Prayer)] = e.Prayer
+		}
+	}
+
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+
+	change := 0
+	synced := make([]string, 0, len(missed))
+	counted := make(map[string]bool, len(qs.ledger.SyncedMissed))
+	for _, key := range qs.ledger.SyncedMissed {
+		if _, ok := missed[key]; ok {
+			counted[key] = true
+			synced = append(synced, key)
+			continue
+		}
+		// The log entry was corrected or removed
+		prayer := models.Prayer(key[len(dateLayout)+1:])
+		if qs.ledger.Outstanding[prayer] > 0 {
+			qs.ledger.Outstanding[prayer]--
+			change--
+		}
+	}
+	for key, prayer := range missed {
+		if counted[key] {
+			continue
+		}
+		qs.ledger.Outstanding[prayer]++
+		synced = append(synced, key)
+		change++
+	}
+
+	if change == 0 && len(synced) == len(qs.ledger.SyncedMissed) {
+		return 0, nil
+	}
+	sort.Strings(synced)
+	qs.ledger.SyncedMissed = synced
+	return change, qs.save()
+}
+
+// GetProjection estimates the completion date from the daily goal.
+func (qs *QadaService) GetProjection(today time.Time) models.QadaProjection {
+	qs.mu.RLock()
+	defer qs.mu.RUnlock()
+
+	projection := models.QadaProjection{
+		TotalOutstanding: qs.ledger.TotalOutstanding(),
+		DailyGoal:        qs.ledger.DailyGoal,
+	}
+
+	// Each day makes up DailyGoal of every prayer, so the prayer with the
+	// largest backlog decides how long it takes.
+	largest := 0
+	for _, count := range qs.ledger.Outstanding {
+		if count > largest {
+			largest = count
+		}
+	}
+	if largest == 0 || qs.ledger.DailyGoal <= 0 {
+		return projection
+	}
+
+	projection.DaysRemaining = (largest + qs.ledger.DailyGoal - 1) / qs.ledger.DailyGoal
+	projection.CompletionDate = today.AddDate(0, 0, projection.DaysRemaining).Format(dateLayout)
+	return projection
+}
+
+// restorableLedger checks a ledger read from a backup or from storage and
+// returns a copy with every prayer present.
+func restorableLedger(ledger models.QadaLedger) (models.QadaLedger, error) {
+	for prayer, count := range ledger.Outstanding {
+		if !isKnownPrayer(prayer) {
+			return models.QadaLedger{}, models.ValidationError("unknown prayer %q", prayer)
+		}
+		if count < 0 {
+			return models.QadaLedger{}, models.ValidationError("outstanding %s count must not be negative", prayer.DisplayName())
+		}
+	}
+	for prayer, count := range ledger.Completed {
+		if !isKnownPrayer(prayer) {
+			return models.QadaLedger{}, models.ValidationError("unknown prayer %q", prayer)
+		}
+		if count < 0 {
+			return models.QadaLedger{}, models.ValidationError("completed %s count must not be negative", prayer.DisplayName())
+		}
+	}
+
+	restored := models.NewQadaLedger()
+	for prayer, count := range ledger.Outstanding {
+		restored.Outstanding[prayer] = count
+	}
+	for prayer, count := range ledger.Completed {
+		restored.Completed[prayer] = count
+	}
+	if ledger.DailyGoal > 0 {
+		restored.DailyGoal = ledger.DailyGoal
+	}
+	if ledger.SyncedMissed != nil {
+		restored.SyncedMissed = append([]string{}, ledger.SyncedMissed...)
+	}
+	restored.UpdatedAt = ledger.UpdatedAt
+	return restored, nil
+}
+
+// save persists the ledger to storage. The caller must hold the lock.
+func (qs *QadaService) save() error {
+	qs.ledger.UpdatedAt = time.Now().UnixMilli()
+	return qs.storage.Save("qada", qs.ledger)
+}
@@ -0,0 +1,387 @@
+// Package services contains business logic for the AzanAlarm application.
+package services
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"AzanAlarm/internal/models"
+)
+
+// MaxUpcomingDays is the largest window accepted when previewing alarms.
+const MaxUpcomingDays = 366
+
+// ScheduleService resolves prayer times and alarm firing instants for
+// concrete dates, combining the current location, settings and alarms.
+type ScheduleService struct {
+	calculator *PrayerCalculator
+	locations  *LocationService
+	settings   *SettingsService
+	alarms     *AlarmService
+	mosques    *MosqueService
+	timetables *TimetableService
+}
+
+// NewScheduleService creates a new ScheduleService instance.
+func NewScheduleService(
+	calculator *PrayerCalculator,
+	locations *LocationService,
+	settings *SettingsService,
+	alarms *AlarmService,
+	mosques *MosqueService,
+	timetables *TimetableService,
+) *ScheduleService {
+	return &ScheduleService{
+		calculator: calculator,
+		locations:  locations,
+		settings:   settings,
+		alarms:     alarms,
+		mosques:    mosques,
+		timetables: timetables,
+	}
+}
+
+// PrayerTimesFor returns the prayer times for the given date at the current
+// location. Times from an imported timetable take precedence over
+// calculated ones. The second return value is false if no location is
+// configured.
+func (ss *ScheduleService) PrayerTimesFor(date time.Time) (models.PrayerTimes, bool) {
+	location := ss.locations.GetCurrentLocation()
+	if location == nil {
+		return models.PrayerTimes{}, false
+	}
+
+	settings := ss.settings.GetSettings()
+
+	times := ss.calculator.Calculate(
+		location.Latitude,
+		location.Longitude,
+		date,
+		settings.CalculationMethod,
+		settings.JuristicMethod,
+		timezoneOffsetFor(date),
+	)
+	times = ss.timetables.ApplyTo(times, location.Latitude, location.Longitude, date)
+
+	if settings.Jumuah.Enabled {
+		if khutbah, ok := ss.jumuahTime(date, times); ok {
+			times.Jumuah = khutbah.Format(time.RFC3339)
+		}
+	}
+	return times, true
+}
+
+// CalculatePrayerTimes calculates the prayer times for the given date at any
+// coordinates with the given methods, in the local timezone. Unlike
+// PrayerTimesFor, imported timetables and Jumu'ah are not applied.
+func (ss *ScheduleService) CalculatePrayerTimes(
+	latitude, longitude float64,
+	date time.Time,
+	method models.PrayerCalculationMethod,
+	juristic models.JuristicMethod,
+) models.PrayerTimes {
+	return ss.calculator.Calculate(latitude, longitude, date, method, juristic, timezoneOffsetFor(date))
+}
+
+// jumuahTime returns the khutbah time on Fridays. The configured time is
+// used first, then the default mosque's Jumu'ah slot, then the Dhuhr time.
+func (ss *ScheduleService) jumuahTime(date time.Time, times models.PrayerTimes) (time.Time, bool) {
+	if date.Weekday() != time.Friday {
+		return time.Time{}, false
+	}
+
+	anchor := models.AlarmAnchor{Type: models.AnchorClock, ClockTime: ss.settings.GetSettings().Jumuah.KhutbahTime}
+	if anchor.ClockTime == "" {
+		if mosque := ss.mosques.GetDefaultMosque(); mosque != nil {
+			anchor.ClockTime = mosque.Jumuah.KhutbahTime
+		}
+	}
+	if anchor.ClockTime != "" {
+		if t, err := anchor.ClockTimeOn(startOfDay(date)); err == nil {
+			return t, true
+		}
+	}
+	return times.ParseTime(models.Dhuhr)
+}
+
+// NextPrayerAt returns the prayer window containing now and the prayer
+// that follows it, with the default mosque's iqamah when it has one.
+func (ss *ScheduleService) NextPrayerAt(now time.Time) models.NextPrayer {
+	if ss.locations.GetCurrentLocation() == nil {
+		return models.NextPrayer{Reason: models.NextPrayerNoLocation}
+	}
+
+	// Yesterday's Isha through tomorrow's Fajr covers every possible window
+	today := startOfDay(now)
+	events := ss.prayerEventsOn(today.AddDate(0, 0, -1))
+	events = append(events, ss.prayerEventsOn(today)...)
+	events = append(events, ss.prayerEventsOn(today.AddDate(0, 0, 1))...)
+
+	for i := 1; i < len(events); i++ {
+		next := events[i]
+		if !next.at.After(now) {
+			continue
+		}
+		current := events[i-1]
+		if current.at.After(now) {
+			break
+		}
+
+		window := next.at.Sub(current.at)
+		elapsed := float64(now.Sub(current.at)) / float64(window) * 100
+
+		result := models.NextPrayer{
+			Available:        true,
+			CurrentPrayer:    current.prayer,
+			CurrentStart:     current.at.Format(time.RFC3339),
+			CurrentEnd:       next.at.Format(time.RFC3339),
+			ElapsedPercent:   math.Round(elapsed*10) / 10,
+			Prayer:           next.prayer,
+			Time:             next.at.Format(time.RFC3339),
+			RemainingSeconds: int(next.at.Sub(now).Seconds()),
+		}
+		ss.addIqamah(&result, next.prayer, next.at, now)
+		return result
+	}
+
+	return models.NextPrayer{Reason: models.NextPrayerNoPrayerTimes}
+}
+
+// prayerEvent is a prayer starting at a concrete instant.
+type prayerEvent struct {
+	prayer models.Prayer
+	at     time.Time
+}
+
+// prayerEventsOn returns the prayers of a date in order, with Jumu'ah in
+// place of Dhuhr on Fridays. Prayers without a time are left out.
+func (ss *ScheduleService) prayerEventsOn(date time.Time) []prayerEvent {
+	times, ok := ss.PrayerTimesFor(date)
+	if !ok {
+		return nil
+	}
+
+	events := make([]prayerEvent, 0, 5)
+	for _, prayer := range models.AllPrayers() {
+		if prayer == models.Dhuhr && times.Jumuah != "" {
+			prayer = models.Jumuah
+		}
+		if t, ok := times.ParseTime(prayer); ok {
+			events = append(events, prayerEvent{prayer: prayer, at: t})
+		}
+	}
+	return events
+}
+
+// addIqamah adds the default mosque's iqamah time and countdown for a
+// prayer to a next-prayer result.
+func (ss *ScheduleService) addIqamah(result *models.NextPrayer, prayer models.Prayer, adhan time.Time, now time.Time) {
+	mosque := ss.mosques.GetDefaultMosque()
+	if mosque == nil {
+		return
+	}
+	if prayer == models.Jumuah {
+		// The mosque's Jumu'ah slot is looked up through Dhuhr
+		prayer = models.Dhuhr
+	}
+	iqamah, ok := mosque.IqamahTime(prayer, adhan, adhan)
+	if !ok {
+		return
+	}
+	result.Mosque = mosque.Name
+	result.IqamahTime = iqamah.Format(time.RFC3339)
+	result.IqamahRemainingSeconds = int(iqamah.Sub(now).Seconds())
+}
+
+// SunTimesFor returns the sun events for the given date at the current
+// location. The second return value is false if no location is configured.
+func (ss *ScheduleService) SunTimesFor(date time.Time) (models.SunTimes, bool) {
+	location := ss.locations.GetCurrentLocation()
+	if location == nil {
+		return models.SunTimes{}, false
+	}
+
+	settings := ss.settings.GetSettings()
+
+	return ss.calculator.CalculateSunTimes(
+		location.Latitude,
+		location.Longitude,
+		date,
+		settings.CalculationMethod,
+		timezoneOffsetFor(date),
+	), true
+}
+
+// ForbiddenWindowsFor returns the windows in which voluntary prayer is
+// disliked on the given date at the current location. The second return
+// value is false if no location is configured.
+func (ss *ScheduleService) ForbiddenWindowsFor(date time.Time) ([]models.ForbiddenWindow, bool) {
+	location := ss.locations.GetCurrentLocation()
+	if location == nil {
+		return make([]models.ForbiddenWindow, 0), false
+	}
+
+	settings := ss.settings.GetSettings()
+
+	return ss.calculator.CalculateForbiddenWindows(
+		location.Latitude,
+		location.Longitude,
+		date,
+		settings.ForbiddenWindows,
+		timezoneOffsetFor(date),
+	), true
+}
+
+// AnchorTime returns the instant of an anchor on the given date, before
+// any alarm offset is applied. The second return value is false if the
+// anchor cannot be resolved, e.g. when no location is configured.
+func (ss *ScheduleService) AnchorTime(anchor models.AlarmAnchor, date time.Time) (time.Time, bool) {
+	return ss.newDaySchedule(date).anchorTime(anchor)
+}
+
+// UpcomingAlarms returns the instants at which active alarms will ring
+// within the given number of days starting at from, sorted by time.
+func (ss *ScheduleService) UpcomingAlarms(from time.Time, days int) []models.AlarmOccurrence {
+	if days <= 0 {
+		return make([]models.AlarmOccurrence, 0)
+	}
+	if days > MaxUpcomingDays {
+		days = MaxUpcomingDays
+	}
+	return ss.AlarmsBetween(from, from.AddDate(0, 0, days))
+}
+
+// AlarmsBetween returns the instants in [from, end) at which active alarms
+// ring, sorted by time.
+func (ss *ScheduleService) AlarmsBetween(from, end time.Time) []models.AlarmOccurrence {
+	occurrences := make([]models.AlarmOccurrence, 0)
+	if !from.Before(end) {
+		return occurrences
+	}
+
+	alarms := ss.alarms.GetActiveAlarms()
+	if len(alarms) == 0 {
+		return occurrences
+	}
+
+	// Offsets can move an alarm across midnight, so look one day either
+	// side of the window and filter by instant.
+	for date := startOfDay(from).AddDate(0, 0, -1); date.Before(end.AddDate(0, 0, 1)); date = date.AddDate(0, 0, 1) {
+		day := ss.newDaySchedule(date)
+
+		for _, alarm := range alarms {
+			if !alarm.ShouldTriggerOnDay(date) {
+				continue
+			}
+			anchor := alarm.EffectiveAnchor()
+			anchorTime, ok := day.anchorTime(anchor)
+			if !ok {
+				continue
+			}
+			at := alarm.GetActualAlarmTime(anchorTime)
+			if at.Before(from) || !at.Before(end) {
+				continue
+			}
+			occurrences = append(occurrences, models.AlarmOccurrence{
+				AlarmID: alarm.ID,
+				Prayer:  alarm.Prayer,
+				Anchor:  anchor,
+				Label:   alarm.DisplayLabel(),
+				Time:    at.Format(time.RFC3339),
+			})
+		}
+	}
+
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		ti, _ := time.Parse(time.RFC3339, occurrences[i].Time)
+		tj, _ := time.Parse(time.RFC3339, occurrences[j].Time)
+		if ti.Equal(tj) {
+			return occurrences[i].AlarmID < occurrences[j].AlarmID
+		}
+		return ti.Before(tj)
+	})
+
+	return occurrences
+}
+
+// daySchedule lazily computes the times alarms can anchor to on one date.
+type daySchedule struct {
+	ss      *ScheduleService
+	date    time.Time
+	prayers *models.PrayerTimes
+	sun     *models.SunTimes
+}
+
+// newDaySchedule creates a daySchedule for the given date.
+func (ss *ScheduleService) newDaySchedule(date time.Time) *daySchedule {
+	return &daySchedule{ss: ss, date: date}
+}
+
+// anchorTime resolves an anchor to an instant on the schedule's date.
+func (d *daySchedule) anchorTime(anchor models.AlarmAnchor) (time.Time, bool) {
+	switch anchor.Type {
+	case models.AnchorPrayer:
+		return d.prayerTime(anchor.Prayer)
+	case models.AnchorIqamah:
+		adhan, ok := d.prayerTime(anchor.Prayer)
+		if !ok {
+			return time.Time{}, false
+		}
+		return d.ss.mosques.IqamahTime(anchor.MosqueID, anchor.Prayer, d.date, adhan)
+	case models.AnchorSunEvent:
+		if d.sun == nil {
+			times, ok := d.ss.SunTimesFor(d.date)
+			if !ok {
+				return time.Time{}, false
+			}
+			d.sun = &times
+		}
+		t, err := time.Parse(time.RFC3339, d.sun.GetTime(anchor.SunEvent))
+		return t, err == nil
+	case models.AnchorClock:
+		t, err := anchor.ClockTimeOn(startOfDay(d.date))
+		return t, err == nil
+	case models.AnchorJumuah:
+		if d.date.Weekday() != time.Friday || !d.loadPrayers() {
+			return time.Time{}, false
+		}
+		return d.ss.jumuahTime(d.date, *d.prayers)
+	default:
+		return time.Time{}, false
+	}
+}
+
+// prayerTime returns the calculated time of a prayer on the schedule's date.
+func (d *daySchedule) prayerTime(prayer models.Prayer) (time.Time, bool) {
+	if !d.loadPrayers() {
+		return time.Time{}, false
+	}
+	return d.prayers.ParseTime(prayer)
+}
+
+// loadPrayers computes the prayer times for the schedule's date once.
+// It returns false if no location is configured.
+func (d *daySchedule) loadPrayers() bool {
+	if d.prayers == nil {
+		times, ok := d.ss.PrayerTimesFor(d.date)
+		if !ok {
+			return false
+		}
+		d.prayers = &times
+	}
+	return true
+}
+
+// timezoneOffsetFor returns the local UTC offset in hours in effect on the
+// given date, so that dates across a DST change use the right offset.
+func timezoneOffsetFor(date time.Time) float64 {
+	noon := time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, time.Local)
+	_, offset := noon.Zone()
+	return float64(offset) / 3600.0
+}
+
+// startOfDay returns midnight local time on the given date.
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
@@ -2,12 +2,15 @@
 package services
 
 import (
+	"sync"
+
 	"AzanAlarm/internal/models"
 )
 
-// SettingsService handles application settings.
+// SettingsService handles application settings. It is safe for concurrent use.
 type SettingsService struct {
 	storage  *StorageService
+	mu       sync.RWMutex
 	settings models.AppSettings
 }
 
@@ -18,8 +21,8 @@ func NewSettingsService(storage *StorageService) *SettingsService {
 		settings: models.DefaultSettings(),
 	}
 
-	// Load existing settings
-	var savedSettings models.AppSettings
+	// Load existing settings; fields missing from the file keep their defaults
+	savedSettings := models.DefaultSettings()
 	if err := storage.Load("settings", &savedSettings); err == nil {
 		ss.settings = savedSettings
 	}
@@ -27,49 +30,153 @@ func NewSettingsService(storage *StorageService) *SettingsService {
 	return ss
 }
 
+// Reload replaces the settings with those in storage, e.g. after the file
+// was edited outside the app. Invalid settings are rejected and the current
+// ones are kept.
+func (ss *SettingsService) Reload() error {
+	settings := models.DefaultSettings()
+	if err := ss.storage.Load("settings", &settings); err != nil {
+		return err
+	}
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.settings = settings
+	return nil
+}
+
 // GetSettings returns the current application settings.
 func (ss *SettingsService) GetSettings() models.AppSettings {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
 	return ss.settings
 }
 
-// SaveSettings saves the application settings.
+// SaveSettings saves the application settings. Enabling the API without a
+// token generates one.
 func (ss *SettingsService) SaveSettings(settings models.AppSettings) error {
+	if settings.API.Enabled && settings.API.Token == "" {
+		token, err := newAPIToken()
+		if err != nil {
+			return err
+		}
+		settings.API.Token = token
+	}
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
 	ss.settings = settings
 	return ss.storage.Save("settings", settings)
 }
 
+// stageRestore returns the step that replaces the settings with those
+// from a backup.
+func (ss *SettingsService) stageRestore(settings models.AppSettings) (restoreStep, error) {
+	if settings.API.Enabled && settings.API.Token == "" {
+		token, err := newAPIToken()
+		if err != nil {
+			return restoreStep{}, err
+		}
+		settings.API.Token = token
+	}
+	if err := settings.Validate(); err != nil {
+		return restoreStep{}, err
+	}
+
+	var previous models.AppSettings
+	return restoreStep{
+		counts: models.RestoreCounts{Section: "settings", Updated: 1},
+		commit: func() error {
+			ss.mu.Lock()
+			defer ss.mu.Unlock()
+
+			previous = ss.settings
+			if err := ss.storage.Save("settings", settings); err != nil {
+				return err
+			}
+			ss.settings = settings
+			return nil
+		},
+		undo: func() error {
+			ss.mu.Lock()
+			defer ss.mu.Unlock()
+
+			ss.settings = previous
+			return ss.storage.Save("settings", previous)
+		},
+	}, nil
+}
+
 // ResetToDefaults resets settings to default values.
 func (ss *SettingsService) ResetToDefaults() error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
 	ss.settings = models.DefaultSettings()
 	return ss.storage.Save("settings", ss.settings)
 }
 
 // UpdateCalculationMethod updates only the calculation method.
 func (ss *SettingsService) UpdateCalculationMethod(method models.PrayerCalculationMethod) error {
-	ss.settings.CalculationMethod = method
-	return ss.storage.Save("settings", ss.settings)
+	return ss.update(func(s *models.AppSettings) { s.CalculationMethod = method })
 }
 
 // UpdateJuristicMethod updates only the juristic method.
 func (ss *SettingsService) UpdateJuristicMethod(method models.JuristicMethod) error {
-	ss.settings.JuristicMethod = method
-	return ss.storage.Save("settings", ss.settings)
+	return ss.update(func(s *models.AppSettings) { s.JuristicMethod = method })
 }
 
 // UpdateTheme updates the application theme.
 func (ss *SettingsService) UpdateTheme(theme models.AppTheme) error {
-	ss.settings.Theme = theme
-	return ss.storage.Save("settings", ss.settings)
+	return ss.update(func(s *models.AppSettings) { s.Theme = theme })
 }
 
 // ToggleNotifications toggles notification enabling.
 func (ss *SettingsService) ToggleNotifications(enable bool) error {
-	ss.settings.EnableNotifications = enable
-	return ss.storage.Save("settings", ss.settings)
+	return ss.update(func(s *models.AppSettings) { s.EnableNotifications = enable })
 }
 
 // Toggle24HourFormat toggles between 12h and 24h time format.
 func (ss *SettingsService) Toggle24HourFormat(enable bool) error {
-	ss.settings.Is24HourFormat = enable
-	return ss.storage.Save("settings", ss.settings)
+	return ss.update(func(s *models.AppSettings) { s.Is24HourFormat = enable })
+}
+
+// RegenerateAPIToken replaces the API token with a new random one, so
+// clients using the old token are refused.
+func (ss *SettingsService) RegenerateAPIToken() (string, error) {
+	token, err := newAPIToken()
+	if err != nil {
+		return "", err
+	}
+	if err := ss.update(func(s *models.AppSettings) { s.API.Token = token }); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// update applies a change to the current settings and saves them, holding
+// the lock so concurrent updates are not lost.
+func (ss *SettingsService) update(change func(*models.AppSettings)) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	settings := ss.settings
+	change(&settings)
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	if err := ss.storage.Save("settings", settings); err != nil {
+		return err
+	}
+	ss.settings = settings
+	return nil
 }
@@ -0,0 +1,176 @@
+// Package services contains business logic for the AzanAlarm application.
+package services
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"AzanAlarm/internal/models"
+)
+
+// Backend names accepted by OpenBackend.
+const (
+	BackendJSON   = "json"   // One JSON file per key in the data directory
+	BackendSQLite = "sqlite" // A single SQLite database in the data directory
+	BackendMemory = "memory" // Nothing is persisted, for tests
+)
+
+// Backend stores the raw, versioned data of each storage key.
+// StorageService adds encoding and schema migrations on top of it.
+//
+// Read and Remove return an error matching fs.ErrNotExist for unknown keys.
+// Implementations must be safe for concurrent use.
+type Backend interface {
+	Name() string
+	Read(key string) ([]byte, error)
+	Write(key string, data []byte) error
+	Remove(key string) error
+	Keys() ([]string, error)
+	Close() error
+}
+
+// warningReporter is implemented by backends that can recover damaged data
+// and report it.
+type warningReporter interface {
+	Warnings() []models.StorageWarning
+}
+
+// unreadableSuffix is appended to the key of data that could not be loaded
+// when it is discarded, so it is kept out of the way rather than deleted.
+const unreadableSuffix = ".unreadable"
+
+// asideSetter is implemented by backends that can move data that cannot be
+// read out of the way, e.g. a damaged file.
+type asideSetter interface {
+	setAside(key string) error
+}
+
+// OpenBackend opens the named backend in the data directory.
+func OpenBackend(name, dataDir string) (Backend, error) {
+	switch name {
+	case BackendJSON:
+		backend, err := NewJSONBackend(dataDir)
+		if err != nil {
+			return nil, err
+		}
+		return backend, nil
+	case BackendSQLite:
+		backend, err := NewSQLiteBackend(dataDir)
+		if err != nil {
+			return nil, err
+		}
+		return backend, nil
+	case BackendMemory:
+		return NewMemoryBackend(), nil
+	default:
+		return nil, models.ValidationError("unknown storage backend %q", name)
+	}
+}
+
+// MigrateBackend copies every key from one backend to another, replacing
+// keys that already exist in the target. Keys that cannot be read, e.g. a
+// damaged file, are skipped and returned as warnings, as are keys set aside
+// by Discard. It returns the number of keys copied. The source is left
+// unchanged.
+func MigrateBackend(from, to Backend) (int, []models.StorageWarning, error) {
+	keys, err := from.Keys()
+	if err != nil {
+		return 0, nil, models.StorageError(err)
+	}
+	copied := 0
+	var skipped []models.StorageWarning
+	for _, key := range keys {
+		if strings.HasSuffix(key, unreadableSuffix) {
+			continue
+		}
+		data, err := from.Read(key)
+		if err != nil {
+			skipped = append(skipped, models.StorageWarning{
+				Key: key,
+				Message: fmt.Sprintf("%s could not be read from the %s storage and was not copied to %s, it is kept in the data directory: %v",
+					key, from.Name(), to.Name(), err),
+				RecoveredAt: time.Now().UnixMilli(),
+				Unreadable:  true,
+			})
+			continue
+		}
+		if err := to.Write(key, data); err != nil {
+			return copied, skipped, models.StorageError(fmt.Errorf("writing %s to the %s storage: %w", key, to.Name(), err))
+		}
+		copied++
+	}
+	return copied, skipped, nil
+}
+
+// MemoryBackend keeps all data in memory. It is meant for tests and for
+// running without a data directory.
+type MemoryBackend struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+// NewMemoryBackend creates an empty MemoryBackend.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{data: make(map[string][]byte)}
+}
+
+// Name returns the backend name.
+func (m *MemoryBackend) Name() string {
+	return BackendMemory
+}
+
+// Read returns a copy of the data stored for a key.
+func (m *MemoryBackend) Read(key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	data, ok := m.data[key]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: key, Err: os.ErrNotExist}
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// Write stores a copy of the data for a key.
+func (m *MemoryBackend) Write(key string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.data[key] = append([]byte(nil), data...)
+	return nil
+}
+
+// Remove deletes a key.
+func (m *MemoryBackend) Remove(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.data[key]; !ok {
+		return &fs.PathError{Op: "remove", Path: key, Err: os.ErrNotExist}
+	}
+	delete(m.data, key)
+	return nil
+}
+
+// Keys returns the stored keys in sorted order.
+func (m *MemoryBackend) Keys() ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := make([]string, 0, len(m.data))
+	for key := range m.data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Close does nothing.
+func (m *MemoryBackend) Close() error {
+	return nil
+}
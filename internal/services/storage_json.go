@@ -0,0 +1,193 @@
+// Package services contains business logic for the AzanAlarm application.
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"AzanAlarm/internal/models"
+)
+
+// backupSuffix is appended to a data file's name for its previous version.
+const backupSuffix = ".bak"
+
+// JSONBackend stores each key in its own JSON file. Writes are atomic and
+// keep the previous version of each file as a backup, which is used when
+// the current file cannot be parsed.
+type JSONBackend struct {
+	dataDir string
+
+	warningsMu sync.Mutex
+	warnings   []models.StorageWarning
+	recovered  map[string]bool // Keys currently served from their backup
+}
+
+// NewJSONBackend creates a JSONBackend in the data directory, creating the
+// directory if needed.
+func NewJSONBackend(dataDir string) (*JSONBackend, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, err
+	}
+	return &JSONBackend{dataDir: dataDir}, nil
+}
+
+// Name returns the backend name.
+func (j *JSONBackend) Name() string {
+	return BackendJSON
+}
+
+// Read returns the contents of a key's file, or of its backup if the file
+// is damaged.
+func (j *JSONBackend) Read(key string) ([]byte, error) {
+	filePath := j.path(key)
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if json.Valid(data) {
+		return data, nil
+	}
+
+	// The file is damaged, e.g. truncated by a crash: fall back to the
+	// previous version
+	backup, err := os.ReadFile(filePath + backupSuffix)
+	if err != nil || !json.Valid(backup) {
+		return nil, fmt.Errorf("%s.json is not valid JSON", key)
+	}
+	j.warn(key)
+	return backup, nil
+}
+
+// Write replaces a key's file, keeping the current version as the backup.
+func (j *JSONBackend) Write(key string, data []byte) error {
+	filePath := j.path(key)
+
+	// Keep the current version as the backup, unless it is damaged and
+	// the backup is the last good copy
+	if current, err := os.ReadFile(filePath); err == nil && json.Valid(current) {
+		if err := writeFileAtomic(filePath+backupSuffix, current); err != nil {
+			return err
+		}
+	}
+
+	if err := writeFileAtomic(filePath, data); err != nil {
+		return err
+	}
+
+	j.warningsMu.Lock()
+	delete(j.recovered, key)
+	j.warningsMu.Unlock()
+	return nil
+}
+
+// Remove deletes a key's file. Its backup is kept.
+func (j *JSONBackend) Remove(key string) error {
+	return os.Remove(j.path(key))
+}
+
+// setAside renames a key's file so the key no longer exists, without
+// reading it, since it may be damaged. Its backup is kept.
+func (j *JSONBackend) setAside(key string) error {
+	return os.Rename(j.path(key), j.path(key)+unreadableSuffix)
+}
+
+// Keys returns the keys that have a data file, in sorted order.
+func (j *JSONBackend) Keys() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(j.dataDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(matches))
+	for _, match := range matches {
+		keys = append(keys, strings.TrimSuffix(filepath.Base(match), ".json"))
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Close does nothing, files are closed after every operation.
+func (j *JSONBackend) Close() error {
+	return nil
+}
+
+// Warnings returns the recoveries from backup since the last call and
+// clears them.
+func (j *JSONBackend) Warnings() []models.StorageWarning {
+	j.warningsMu.Lock()
+	defer j.warningsMu.Unlock()
+
+	warnings := j.warnings
+	j.warnings = nil
+	return warnings
+}
+
+// warn records that a key was loaded from its backup. Each key is reported
+// once until it is saved again.
+func (j *JSONBackend) warn(key string) {
+	j.warningsMu.Lock()
+	defer j.warningsMu.Unlock()
+
+	if j.recovered == nil {
+		j.recovered = make(map[string]bool)
+	}
+	if j.recovered[key] {
+		return
+	}
+	j.recovered[key] = true
+	j.warnings = append(j.warnings, models.StorageWarning{
+		Key:         key,
+		Message:     fmt.Sprintf("%s.json was damaged, the previous version was loaded instead", key),
+		RecoveredAt: time.Now().UnixMilli(),
+	})
+}
+
+// path returns the file path of a key.
+func (j *JSONBackend) path(key string) string {
+	return filepath.Join(j.dataDir, key+".json")
+}
+
+// writeFileAtomic replaces a file by writing a temporary file in the same
+// directory, syncing it to disk and renaming it over the target, so the
+// target is never left partially written.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+
+	// Persist the rename itself; not supported on every platform
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
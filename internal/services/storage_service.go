@@ -2,73 +2,307 @@
 package services
 
 import (
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
+	"time"
+
+	"AzanAlarm/internal/models"
 )
 
-// StorageService handles persistent storage of application data.
+// SQLiteFileName is the name of the SQLite database in the data directory.
+// Its presence selects the SQLite backend on startup.
+const SQLiteFileName = "azanalarm.db"
+
+// StorageService handles persistent storage of application data. It
+// encodes values with their schema version and migrates older data on
+// load; the bytes themselves are kept by a Backend.
 type StorageService struct {
 	dataDir string
 	mu      sync.RWMutex
+	backend Backend
+	err     error // Set when the data directory cannot be used
+
+	hashMu sync.Mutex
+	hashes map[string][sha256.Size]byte // Last data saved or loaded per key
+
+	unreadableMu sync.Mutex
+	unreadable   map[string]error        // Keys that failed to load, which Save refuses to overwrite
+	reported     map[string]bool         // Unreadable keys already returned by Warnings
+	errReported  bool                    // Whether Warnings returned err
+	skipped      []models.StorageWarning // Keys left behind by MigrateTo, not yet returned by Warnings
 }
 
-// NewStorageService creates a new StorageService instance.
-func NewStorageService() (*StorageService, error) {
-	// Get user config directory
-	configDir, err := os.UserConfigDir()
-	if err != nil {
+// NewStorageService creates a new StorageService instance in the data
+// directory, creating it if needed; see ResolveDataDir. The SQLite backend
+// is used if its database exists, otherwise data is kept in JSON files.
+func NewStorageService(dataDir string) (*StorageService, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		return nil, err
 	}
 
-	dataDir := filepath.Join(configDir, "AzanAlarm")
-	if err := os.MkdirAll(dataDir, 0755); err != nil {
+	name := BackendJSON
+	if _, err := os.Stat(filepath.Join(dataDir, SQLiteFileName)); err == nil {
+		name = BackendSQLite
+	}
+	backend, err := OpenBackend(name, dataDir)
+	if err != nil {
 		return nil, err
 	}
 
 	return &StorageService{
 		dataDir: dataDir,
+		backend: backend,
 	}, nil
 }
 
-// Save saves data to a file.
+// NewStorageServiceWithBackend creates a StorageService on top of the given
+// backend, e.g. a MemoryBackend in tests. dataDir may be empty if the
+// backend does not use one.
+func NewStorageServiceWithBackend(backend Backend, dataDir string) *StorageService {
+	return &StorageService{
+		dataDir: dataDir,
+		backend: backend,
+	}
+}
+
+// NewUnavailableStorage creates a StorageService whose reads and writes all
+// fail with err, so the app can still start without its data directory.
+func NewUnavailableStorage(err error) *StorageService {
+	return &StorageService{err: err}
+}
+
+// Save saves data under a key. Keys whose stored data could not be loaded
+// are not overwritten, so data that is damaged or from a newer version is
+// not replaced by whatever the services started with instead.
 func (s *StorageService) Save(key string, data interface{}) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	filePath := filepath.Join(s.dataDir, key+".json")
+	if s.err != nil {
+		return models.StorageError(s.err)
+	}
+	if err := s.unreadableErr(key); err != nil {
+		return models.StorageError(fmt.Errorf("not saving %s, its stored data could not be read: %w", key, err))
+	}
 
-	jsonData, err := json.MarshalIndent(data, "", "  ")
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	jsonData, err := json.MarshalIndent(envelope{Version: SchemaVersion, Data: payload}, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(filePath, jsonData, 0644)
+	if err := s.backend.Write(key, jsonData); err != nil {
+		return models.StorageError(err)
+	}
+	s.remember(key, jsonData)
+	return nil
 }
 
-// Load loads data from a file.
+// Load loads data from a key, upgrading it to the current schema version.
+// Fields missing from the stored data keep the values already in data, so
+// callers can pass in defaults. If the data exists but cannot be loaded,
+// saving the key is refused until it loads again.
 func (s *StorageService) Load(key string, data interface{}) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	filePath := filepath.Join(s.dataDir, key+".json")
+	if s.err != nil {
+		return models.StorageError(s.err)
+	}
+
+	raw, err := s.backend.Read(key)
+	if errors.Is(err, fs.ErrNotExist) {
+		s.setUnreadable(key, nil)
+		return err
+	} else if err != nil {
+		s.setUnreadable(key, err)
+		return models.StorageError(err)
+	}
+	if err := s.decode(key, raw, data); err != nil {
+		s.setUnreadable(key, err)
+		if models.AsAppError(err).Code == models.CodeInternal {
+			return models.StorageError(fmt.Errorf("%s: %w", key, err))
+		}
+		return err
+	}
+	s.setUnreadable(key, nil)
+	s.remember(key, raw)
+	return nil
+}
+
+// setUnreadable records that a key failed to load, or clears it if err is
+// nil.
+func (s *StorageService) setUnreadable(key string, err error) {
+	s.unreadableMu.Lock()
+	defer s.unreadableMu.Unlock()
+
+	if err == nil {
+		delete(s.unreadable, key)
+		delete(s.reported, key)
+		return
+	}
+	if s.unreadable == nil {
+		s.unreadable = make(map[string]error)
+	}
+	s.unreadable[key] = err
+}
+
+// unreadableErr returns why a key failed to load, or nil.
+func (s *StorageService) unreadableErr(key string) error {
+	s.unreadableMu.Lock()
+	defer s.unreadableMu.Unlock()
+
+	return s.unreadable[key]
+}
+
+// Changed checks if the data stored for a key differs from what this
+// service last saved or loaded, i.e. whether it was changed by someone
+// else. It returns an error matching fs.ErrNotExist if the key is gone.
+func (s *StorageService) Changed(key string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.err != nil {
+		return false, models.StorageError(s.err)
+	}
 
-	jsonData, err := os.ReadFile(filePath)
+	raw, err := s.backend.Read(key)
+	if err != nil {
+		return false, err
+	}
+	s.hashMu.Lock()
+	defer s.hashMu.Unlock()
+	last, ok := s.hashes[key]
+	return !ok || last != sha256.Sum256(raw), nil
+}
+
+// remember records the data last saved or loaded for a key.
+func (s *StorageService) remember(key string, raw []byte) {
+	s.hashMu.Lock()
+	defer s.hashMu.Unlock()
+
+	if s.hashes == nil {
+		s.hashes = make(map[string][sha256.Size]byte)
+	}
+	s.hashes[key] = sha256.Sum256(raw)
+}
+
+// decode unwraps stored data, migrates its payload and decodes it.
+func (s *StorageService) decode(key string, raw []byte, data interface{}) error {
+	payload, version := unwrap(raw)
+	payload, err := migrate(key, payload, version)
 	if err != nil {
 		return err
 	}
+	return json.Unmarshal(payload, data)
+}
+
+// Warnings returns the recoveries from backup since the last call and
+// clears them. Keys whose data could not be loaded or was not copied by
+// MigrateTo, and storage that could not be opened at all, are reported once.
+func (s *StorageService) Warnings() []models.StorageWarning {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var warnings []models.StorageWarning
+	if reporter, ok := s.backend.(warningReporter); ok {
+		warnings = reporter.Warnings()
+	}
+
+	s.unreadableMu.Lock()
+	defer s.unreadableMu.Unlock()
 
-	return json.Unmarshal(jsonData, data)
+	if s.err != nil && !s.errReported {
+		s.errReported = true
+		warnings = append(warnings, models.StorageWarning{
+			Message:     models.StorageError(s.err).Error() + ", nothing can be loaded or saved",
+			RecoveredAt: time.Now().UnixMilli(),
+			Unreadable:  true,
+		})
+	}
+
+	warnings = append(warnings, s.skipped...)
+	s.skipped = nil
+
+	keys := make([]string, 0, len(s.unreadable))
+	for key := range s.unreadable {
+		if !s.reported[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if s.reported == nil && len(keys) > 0 {
+		s.reported = make(map[string]bool)
+	}
+	for _, key := range keys {
+		s.reported[key] = true
+		warnings = append(warnings, models.StorageWarning{
+			Key:         key,
+			Message:     fmt.Sprintf("%s could not be loaded and will not be changed until it is fixed or discarded: %v", key, s.unreadable[key]),
+			RecoveredAt: time.Now().UnixMilli(),
+			Unreadable:  true,
+		})
+	}
+	return warnings
+}
+
+// Discard moves the data of a key that could not be loaded out of the way,
+// so the key can be saved again, starting over with no data. The data is
+// kept under the key with an ".unreadable" suffix where the backend allows.
+func (s *StorageService) Discard(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return models.StorageError(s.err)
+	}
+	if s.unreadableErr(key) == nil {
+		return models.ValidationError("%s was loaded, there is nothing to discard", key)
+	}
+
+	var err error
+	if setter, ok := s.backend.(asideSetter); ok {
+		err = setter.setAside(key)
+	} else {
+		// Keep a copy if the data can be read, e.g. from a newer version
+		if raw, readErr := s.backend.Read(key); readErr == nil {
+			err = s.backend.Write(key+unreadableSuffix, raw)
+		}
+		if err == nil {
+			err = s.backend.Remove(key)
+		}
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return models.StorageError(err)
+	}
+	s.setUnreadable(key, nil)
+	return nil
 }
 
-// Delete removes a storage file.
+// Delete removes a key.
 func (s *StorageService) Delete(key string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	filePath := filepath.Join(s.dataDir, key+".json")
-	return os.Remove(filePath)
+	if s.err != nil {
+		return models.StorageError(s.err)
+	}
+
+	err := s.backend.Remove(key)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return models.StorageError(err)
+	}
+	return err
 }
 
 // Exists checks if a storage key exists.
@@ -76,11 +310,106 @@ func (s *StorageService) Exists(key string) bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	filePath := filepath.Join(s.dataDir, key+".json")
-	_, err := os.Stat(filePath)
+	if s.err != nil {
+		return false
+	}
+
+	_, err := s.backend.Read(key)
 	return err == nil
 }
 
+// Keys returns all stored keys in sorted order.
+func (s *StorageService) Keys() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.err != nil {
+		return nil, models.StorageError(s.err)
+	}
+
+	keys, err := s.backend.Keys()
+	if err != nil {
+		return nil, models.StorageError(err)
+	}
+	return keys, nil
+}
+
+// BackendName returns the name of the backend in use, or "" if storage is
+// unavailable.
+func (s *StorageService) BackendName() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.backend == nil {
+		return ""
+	}
+	return s.backend.Name()
+}
+
+// MigrateTo copies all data to the named backend and switches to it. The
+// old backend's data is kept: JSON files stay in place and the SQLite
+// database is renamed with a backup suffix, so the next start opens the
+// new backend. Keys that cannot be read are left behind and reported by
+// Warnings. It returns the number of keys copied.
+func (s *StorageService) MigrateTo(name string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return 0, models.StorageError(s.err)
+	}
+	if name == BackendMemory {
+		return 0, models.ValidationError("the memory backend cannot be selected")
+	}
+	if name == s.backend.Name() {
+		return 0, models.ValidationError("storage already uses the %s backend", name)
+	}
+
+	target, err := OpenBackend(name, s.dataDir)
+	if err != nil {
+		if models.AsAppError(err).Code == models.CodeValidation {
+			return 0, err
+		}
+		return 0, models.StorageError(err)
+	}
+	copied, skipped, err := MigrateBackend(s.backend, target)
+	if err != nil {
+		target.Close()
+		if name == BackendSQLite {
+			os.Remove(filepath.Join(s.dataDir, SQLiteFileName))
+		}
+		return 0, err
+	}
+
+	s.unreadableMu.Lock()
+	s.skipped = append(s.skipped, skipped...)
+	s.unreadableMu.Unlock()
+
+	previous := s.backend
+	s.backend = target
+	previous.Close()
+	if previous.Name() == BackendSQLite {
+		dbPath := filepath.Join(s.dataDir, SQLiteFileName)
+		if err := os.Rename(dbPath, dbPath+backupSuffix); err != nil {
+			return copied, models.StorageError(err)
+		}
+		os.Remove(dbPath + "-wal")
+		os.Remove(dbPath + "-shm")
+	}
+	return copied, nil
+}
+
+// Close closes the backend.
+func (s *StorageService) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.backend == nil {
+		return nil
+	}
+	return s.backend.Close()
+}
+
 // GetDataDir returns the data directory path.
 func (s *StorageService) GetDataDir() string {
 	return s.dataDir
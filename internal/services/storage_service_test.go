@@ -0,0 +1,136 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"AzanAlarm/internal/models"
+)
+
+// TestUnreadableDataIsNotSavedOver starts the alarms on a data directory
+// whose alarms file and backup are both damaged.
+func TestUnreadableDataIsNotSavedOver(t *testing.T) {
+	dataDir := t.TempDir()
+	damaged := []byte(`[{"id":1,"prayer":"fajr"`)
+	for _, name := range []string{"alarms.json", "alarms.json.bak"} {
+		if err := os.WriteFile(filepath.Join(dataDir, name), damaged, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	storage, err := NewStorageService(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	as := NewAlarmService(storage)
+	if len(as.GetAlarms()) != 0 {
+		t.Fatal("damaged alarms should not load")
+	}
+
+	warnings := storage.Warnings()
+	if len(warnings) != 1 || warnings[0].Key != "alarms" || !warnings[0].Unreadable {
+		t.Fatalf("got warnings %+v, want one for the unreadable alarms", warnings)
+	}
+	if again := storage.Warnings(); len(again) != 0 {
+		t.Errorf("unreadable alarms were reported twice: %+v", again)
+	}
+
+	if _, err := as.CreateAlarm(models.NewAlarm(models.Fajr, 0)); !errors.Is(err, models.ErrStorageUnavailable) {
+		t.Fatalf("creating an alarm: got %v, want a storage error", err)
+	}
+	if raw, _ := os.ReadFile(filepath.Join(dataDir, "alarms.json")); string(raw) != string(damaged) {
+		t.Fatalf("damaged alarms were overwritten with %s", raw)
+	}
+
+	// Discarding keeps the file aside and allows saving again
+	if err := storage.Discard("alarms"); err != nil {
+		t.Fatalf("discarding: %v", err)
+	}
+	if raw, _ := os.ReadFile(filepath.Join(dataDir, "alarms.json"+unreadableSuffix)); string(raw) != string(damaged) {
+		t.Errorf("damaged alarms were not kept aside, got %s", raw)
+	}
+	if _, err := as.CreateAlarm(models.NewAlarm(models.Fajr, 0)); err != nil {
+		t.Errorf("creating an alarm after discarding: %v", err)
+	}
+	if err := storage.Discard("alarms"); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("discarding readable alarms: got %v, want a validation error", err)
+	}
+}
+
+// TestUnreadableDataLoadsAgain checks that fixing the data outside the app
+// allows saving again.
+func TestUnreadableDataLoadsAgain(t *testing.T) {
+	backend := NewMemoryBackend()
+	if err := backend.Write("alarms", []byte(`{"version":99,"data":[]}`)); err != nil {
+		t.Fatal(err)
+	}
+	storage := NewStorageServiceWithBackend(backend, "")
+	as := NewAlarmService(storage)
+
+	if err := backend.Write("alarms", []byte(`{"version":1,"data":[]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := as.Reload(); err != nil {
+		t.Fatalf("reloading: %v", err)
+	}
+	if _, err := as.CreateAlarm(models.NewAlarm(models.Fajr, 0)); err != nil {
+		t.Errorf("creating an alarm after the data was fixed: %v", err)
+	}
+}
+
+// TestDamagedDataLoadsFromBackup damages the alarms file after two saves,
+// leaving the first save as the backup.
+func TestDamagedDataLoadsFromBackup(t *testing.T) {
+	dataDir := t.TempDir()
+	storage, err := NewStorageService(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	as := NewAlarmService(storage)
+	for _, label := range []string{"a", "b"} {
+		alarm := models.NewAlarm(models.Fajr, 0)
+		alarm.Label = label
+		if _, err := as.CreateAlarm(alarm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	damage := func() {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dataDir, "alarms.json"), []byte(`[{"id":1,`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	damage()
+
+	storage, err = NewStorageService(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	as = NewAlarmService(storage)
+	if alarms := as.GetAlarms(); len(alarms) != 1 || alarms[0].Label != "a" {
+		t.Fatalf("got alarms %+v, want only a from the backup", alarms)
+	}
+
+	warnings := storage.Warnings()
+	if len(warnings) != 1 || warnings[0].Key != "alarms" || warnings[0].Unreadable {
+		t.Fatalf("got warnings %+v, want one recovery of the alarms", warnings)
+	}
+	if again := storage.Warnings(); len(again) != 0 {
+		t.Errorf("the recovery was reported twice: %+v", again)
+	}
+
+	// Recovered data can be saved, which ends the recovery: damaging the
+	// file again is reported again
+	if _, err := as.CreateAlarm(models.NewAlarm(models.Fajr, 0)); err != nil {
+		t.Fatalf("creating an alarm: %v", err)
+	}
+	damage()
+	if err := as.Reload(); err != nil {
+		t.Fatalf("reloading: %v", err)
+	}
+	if warnings := storage.Warnings(); len(warnings) != 1 || warnings[0].Key != "alarms" {
+		t.Errorf("got warnings %+v after saving and damaging again, want one recovery", warnings)
+	}
+}
@@ -0,0 +1,110 @@
+// Package services contains business logic for the AzanAlarm application.
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite" // Pure-Go driver, no cgo needed
+)
+
+// SQLiteAvailable reports whether this build includes the SQLite backend.
+// The driver is pure Go, so it is included on every platform.
+const SQLiteAvailable = true
+
+// SQLiteBackend stores every key as a row in a single SQLite database,
+// which scales better than JSON files for large prayer logs and alarm
+// histories.
+type SQLiteBackend struct {
+	db *sql.DB
+}
+
+// NewSQLiteBackend opens or creates the database in the data directory.
+func NewSQLiteBackend(dataDir string) (*SQLiteBackend, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", sqliteDSN(filepath.Join(dataDir, SQLiteFileName)))
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; one connection avoids busy errors
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS kv (
+		key        TEXT PRIMARY KEY,
+		data       BLOB NOT NULL,
+		updated_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
+	)`); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteBackend{db: db}, nil
+}
+
+// sqliteDSN returns the connection string for a database file, with a
+// journal that survives crashes without blocking readers.
+func sqliteDSN(path string) string {
+	return "file:" + filepath.ToSlash(path) + "?_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_pragma=busy_timeout(5000)"
+}
+
+// Name returns the backend name.
+func (s *SQLiteBackend) Name() string {
+	return BackendSQLite
+}
+
+// Read returns the data stored for a key.
+func (s *SQLiteBackend) Read(key string) ([]byte, error) {
+	var data []byte
+	err := s.db.QueryRow(`SELECT data FROM kv WHERE key = ?`, key).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &fs.PathError{Op: "read", Path: key, Err: os.ErrNotExist}
+	}
+	return data, err
+}
+
+// Write inserts or replaces the data for a key in a single transaction.
+func (s *SQLiteBackend) Write(key string, data []byte) error {
+	_, err := s.db.Exec(`INSERT INTO kv (key, data, updated_at) VALUES (?, ?, strftime('%s', 'now'))
+		ON CONFLICT(key) DO UPDATE SET data = excluded.data, updated_at = excluded.updated_at`, key, data)
+	return err
+}
+
+// Remove deletes a key.
+func (s *SQLiteBackend) Remove(key string) error {
+	result, err := s.db.Exec(`DELETE FROM kv WHERE key = ?`, key)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return &fs.PathError{Op: "remove", Path: key, Err: os.ErrNotExist}
+	}
+	return nil
+}
+
+// Keys returns the stored keys in sorted order.
+func (s *SQLiteBackend) Keys() ([]string, error) {
+	rows, err := s.db.Query(`SELECT key FROM kv ORDER BY key`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]string, 0)
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// Close closes the database.
+func (s *SQLiteBackend) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"AzanAlarm/internal/models"
+)
+
+func TestSQLiteBackendReadWrite(t *testing.T) {
+	backend, err := NewSQLiteBackend(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.Close()
+
+	if _, err := backend.Read("alarms"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("reading a missing key: got %v, want fs.ErrNotExist", err)
+	}
+	for _, data := range []string{`{"version":1,"data":[]}`, `{"version":1,"data":[{"id":1}]}`} {
+		if err := backend.Write("alarms", []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		got, err := backend.Read("alarms")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != data {
+			t.Errorf("got %s, want %s", got, data)
+		}
+	}
+	if keys, err := backend.Keys(); err != nil || len(keys) != 1 || keys[0] != "alarms" {
+		t.Errorf("got keys %v, %v, want [alarms]", keys, err)
+	}
+	if err := backend.Remove("alarms"); err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.Remove("alarms"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("removing a missing key: got %v, want fs.ErrNotExist", err)
+	}
+}
+
+// TestMigrateToSQLite moves data from JSON files to SQLite and checks the
+// next start opens the database.
+func TestMigrateToSQLite(t *testing.T) {
+	dataDir := t.TempDir()
+	storage, err := NewStorageService(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	as := NewAlarmService(storage)
+	if _, err := as.CreateAlarm(models.NewAlarm(models.Asr, 5)); err != nil {
+		t.Fatal(err)
+	}
+
+	copied, err := storage.MigrateTo(BackendSQLite)
+	if err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+	if copied != 1 {
+		t.Errorf("copied %d keys, want 1", copied)
+	}
+	storage.Close()
+	if _, err := os.Stat(filepath.Join(dataDir, SQLiteFileName)); err != nil {
+		t.Fatalf("database was not created: %v", err)
+	}
+
+	reopened, err := NewStorageService(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if name := reopened.BackendName(); name != BackendSQLite {
+		t.Errorf("reopened with the %s backend, want sqlite", name)
+	}
+	if alarms := NewAlarmService(reopened).GetAlarms(); len(alarms) != 1 || alarms[0].Prayer != models.Asr {
+		t.Errorf("got alarms %+v after migrating, want the Asr alarm", alarms)
+	}
+}
+
+// TestMigrateToSQLiteSkipsUnreadableData migrates a data directory whose
+// prayer log is damaged and has no backup.
+func TestMigrateToSQLiteSkipsUnreadableData(t *testing.T) {
+	dataDir := t.TempDir()
+	storage, err := NewStorageService(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer storage.Close()
+	as := NewAlarmService(storage)
+	if _, err := as.CreateAlarm(models.NewAlarm(models.Asr, 5)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dataDir, "prayer_log.json"), []byte(`[{"date":`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	copied, err := storage.MigrateTo(BackendSQLite)
+	if err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+	if copied != 1 {
+		t.Errorf("copied %d keys, want 1", copied)
+	}
+	warnings := storage.Warnings()
+	if len(warnings) != 1 || warnings[0].Key != "prayer_log" || !warnings[0].Unreadable {
+		t.Fatalf("got warnings %+v, want one for the prayer log", warnings)
+	}
+	if again := storage.Warnings(); len(again) != 0 {
+		t.Errorf("the skipped prayer log was reported twice: %+v", again)
+	}
+	if name := storage.BackendName(); name != BackendSQLite {
+		t.Errorf("using the %s backend, want sqlite", name)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "prayer_log.json")); err != nil {
+		t.Errorf("the damaged prayer log was not kept: %v", err)
+	}
+}
+
+func TestMigrateBackendSkipsDiscardedData(t *testing.T) {
+	from := NewMemoryBackend()
+	for _, key := range []string{"alarms", "alarms" + unreadableSuffix} {
+		if err := from.Write(key, []byte(`{"version":1,"data":[]}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	to := NewMemoryBackend()
+
+	copied, skipped, err := MigrateBackend(from, to)
+	if err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+	if copied != 1 || len(skipped) != 0 {
+		t.Errorf("copied %d keys and skipped %+v, want 1 copied", copied, skipped)
+	}
+	if keys, _ := to.Keys(); len(keys) != 1 || keys[0] != "alarms" {
+		t.Errorf("target holds keys %v, want [alarms]", keys)
+	}
+}
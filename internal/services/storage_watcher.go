@@ -0,0 +1,168 @@
+// Package services contains business logic for the AzanAlarm application.
+package services
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"AzanAlarm/internal/models"
+)
+
+// watchSettleDelay is how long a data file must stay unchanged before it is
+// reloaded, so editors and sync tools can finish writing it.
+const watchSettleDelay = 250 * time.Millisecond
+
+// StorageWatcher watches the data directory of the JSON backend for files
+// changed by other programs, such as a text editor or a sync tool, and
+// reloads them.
+type StorageWatcher struct {
+	storage *StorageService
+	watcher *fsnotify.Watcher
+
+	mu        sync.Mutex
+	reloaders map[string]func() error // nil reloaders only report the change
+	pending   map[string]*time.Timer
+	settled   chan string
+	done      chan struct{} // Closed when Run returns
+}
+
+// NewStorageWatcher starts watching the data directory. It fails if the
+// storage does not use the JSON backend.
+func NewStorageWatcher(storage *StorageService) (*StorageWatcher, error) {
+	if storage.BackendName() != BackendJSON {
+		return nil, errors.New("only the JSON backend can be watched for changes")
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(storage.GetDataDir()); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	return &StorageWatcher{
+		storage:   storage,
+		watcher:   watcher,
+		reloaders: make(map[string]func() error),
+		pending:   make(map[string]*time.Timer),
+		settled:   make(chan string, 16),
+		done:      make(chan struct{}),
+	}, nil
+}
+
+// OnChange registers the function that reloads a key when its file is
+// changed by another program. A nil function only reports the change.
+// Keys that are not registered are ignored.
+func (w *StorageWatcher) OnChange(key string, reload func() error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.reloaders[key] = reload
+}
+
+// Run processes file changes until the context is cancelled, calling
+// report after each reload attempt.
+func (w *StorageWatcher) Run(ctx context.Context, report func(models.StorageChange)) {
+	defer close(w.done)
+	defer w.watcher.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.mu.Lock()
+			for _, timer := range w.pending {
+				timer.Stop()
+			}
+			w.mu.Unlock()
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) || event.Has(fsnotify.Rename) {
+				w.schedule(event.Name)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			report(models.StorageChange{Error: err.Error()})
+		case key := <-w.settled:
+			if change, ok := w.reload(key); ok {
+				report(change)
+			}
+		}
+	}
+}
+
+// schedule queues the key of a changed file for reloading once it has
+// settled. Files that do not belong to a registered key are ignored.
+func (w *StorageWatcher) schedule(path string) {
+	name := filepath.Base(path)
+	if !strings.HasSuffix(name, ".json") {
+		return // Backups and temporary files
+	}
+	key := strings.TrimSuffix(name, ".json")
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.reloaders[key]; !ok {
+		return
+	}
+	if timer, ok := w.pending[key]; ok {
+		timer.Reset(watchSettleDelay)
+		return
+	}
+	w.pending[key] = time.AfterFunc(watchSettleDelay, func() {
+		w.mu.Lock()
+		delete(w.pending, key)
+		w.mu.Unlock()
+		select {
+		case w.settled <- key:
+		case <-w.done:
+		}
+	})
+}
+
+// reload reloads a key if its data differs from what the app last saved
+// or loaded. It returns false if there was nothing to do.
+func (w *StorageWatcher) reload(key string) (models.StorageChange, bool) {
+	change := models.StorageChange{Key: key}
+
+	changed, err := w.storage.Changed(key)
+	if errors.Is(err, fs.ErrNotExist) {
+		return change, false // Deleted or mid-rename, the next event follows
+	}
+	if err != nil {
+		change.Error = err.Error()
+		return change, true
+	}
+	if !changed {
+		return change, false // The app's own save
+	}
+
+	w.mu.Lock()
+	reload := w.reloaders[key]
+	w.mu.Unlock()
+	if reload == nil {
+		// Read on demand; load once so the same contents are not reported again
+		var ignored interface{}
+		if err := w.storage.Load(key, &ignored); err != nil {
+			change.Error = err.Error()
+		}
+		return change, true
+	}
+	if err := reload(); err != nil {
+		change.Error = err.Error()
+	}
+	return change, true
+}
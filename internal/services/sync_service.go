@@ -0,0 +1,452 @@
+// Package services contains business logic for the AzanAlarm application.
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"AzanAlarm/internal/models"
+)
+
+// syncTombstoneTTL is how long deletions are remembered. A device that has
+// not synced for longer may bring deleted items back.
+const syncTombstoneTTL = 90 * 24 * time.Hour
+
+// syncState is what this device remembers between syncs.
+type syncState struct {
+	DeviceID           string              `json:"deviceId"`
+	Alarms             map[string]int64    `json:"alarms"`    // Sync IDs present after the last sync
+	Locations          map[string]int64    `json:"locations"` // Sync keys present after the last sync
+	AlarmTombstones    []models.SyncRecord `json:"alarmTombstones"`
+	LocationTombstones []models.SyncRecord `json:"locationTombstones"`
+}
+
+// SyncService keeps alarms and saved locations in step with other devices
+// through a shared folder, such as a NAS share or a Syncthing folder. Each
+// device writes its records to its own file in the folder and merges the
+// files of the others; for every record the latest UpdatedAt wins, so edits
+// to different alarms merge cleanly. Deletions are kept as tombstones.
+// Mosques are not synced, so iqamah alarms from other devices are reported
+// as conflicts instead of being applied.
+type SyncService struct {
+	storage   *StorageService
+	settings  *SettingsService
+	alarms    *AlarmService
+	locations *LocationService
+	profiles  *ProfileService
+	mu        sync.Mutex // Serialises syncs
+	state     syncState
+}
+
+// NewSyncService creates a new SyncService instance.
+func NewSyncService(storage *StorageService, settings *SettingsService, alarms *AlarmService, locations *LocationService, profiles *ProfileService) *SyncService {
+	ss := &SyncService{
+		storage:   storage,
+		settings:  settings,
+		alarms:    alarms,
+		locations: locations,
+		profiles:  profiles,
+	}
+
+	// Load existing state
+	_ = storage.Load("sync_state", &ss.state)
+
+	return ss
+}
+
+// Reload replaces the sync state with the one in storage, e.g. after the
+// file was replaced outside the app.
+func (ss *SyncService) Reload() error {
+	var state syncState
+	if err := ss.storage.Load("sync_state", &state); err != nil {
+		return err
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.state = state
+	return nil
+}
+
+// IsConfigured checks if a sync folder is set.
+func (ss *SyncService) IsConfigured() bool {
+	return ss.settings.GetSettings().Sync.Directory != ""
+}
+
+// Sync merges this device's alarms and saved locations with those of the
+// other devices in the sync folder, then publishes the result.
+func (ss *SyncService) Sync(now time.Time) (models.SyncResult, error) {
+	dir := ss.settings.GetSettings().Sync.Directory
+	if dir == "" {
+		return models.SyncResult{}, models.NotConfiguredError("no sync folder set")
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	before, _ := json.Marshal(ss.state)
+	if ss.state.DeviceID == "" {
+		ss.state.DeviceID = newSyncID()
+	}
+	if err := ss.alarms.AssignSyncIDs(); err != nil {
+		return models.SyncResult{}, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return models.SyncResult{}, models.StorageError(err)
+	}
+	nowMillis := now.UnixMilli()
+
+	localAlarms, err := alarmRecords(ss.alarms.GetAlarms())
+	if err != nil {
+		return models.SyncResult{}, err
+	}
+	localLocations, err := locationRecords(ss.locations.GetSavedLocations())
+	if err != nil {
+		return models.SyncResult{}, err
+	}
+
+	// Items present at the last sync but gone now were deleted here
+	ss.state.AlarmTombstones = addTombstones(ss.state.AlarmTombstones, ss.state.Alarms, localAlarms, nowMillis)
+	ss.state.LocationTombstones = addTombstones(ss.state.LocationTombstones, ss.state.Locations, localLocations, nowMillis)
+
+	remotes, err := readSyncFiles(dir, ss.state.DeviceID)
+	if err != nil {
+		return models.SyncResult{}, err
+	}
+	remoteAlarms := make([][]models.SyncRecord, 0, len(remotes))
+	remoteLocations := make([][]models.SyncRecord, 0, len(remotes))
+	devices := make(map[string]bool)
+	for _, f := range remotes {
+		remoteAlarms = append(remoteAlarms, f.Alarms)
+		remoteLocations = append(remoteLocations, f.Locations)
+		devices[f.DeviceID] = true
+	}
+
+	result := models.SyncResult{
+		SyncedAt:  now.Format(time.RFC3339),
+		Devices:   len(devices),
+		Conflicts: []models.SyncConflict{},
+	}
+
+	// Alarms
+	alarmWinners := mergeRecords(localAlarms, ss.state.AlarmTombstones, remoteAlarms)
+	upserts := make([]models.Alarm, 0)
+	deletes := make([]string, 0)
+	for id, winner := range alarmWinners {
+		local, ok := localAlarms[id]
+		switch {
+		case winner.Deleted:
+			if ok {
+				deletes = append(deletes, id)
+			}
+		case !ok || winner.UpdatedAt > local.UpdatedAt:
+			var alarm models.Alarm
+			if err := json.Unmarshal(winner.Data, &alarm); err != nil {
+				continue // Written by an incompatible version
+			}
+			alarm.SyncID = id
+			alarm.NormalizeAnchor()
+			if alarm.Validate() != nil {
+				continue // Uses features this version does not know
+			}
+			// Mosques are not synced, so the mosque ID means nothing here
+			if alarm.Anchor.Type == models.AnchorIqamah {
+				result.Conflicts = append(result.Conflicts, iqamahConflict(alarm))
+				continue
+			}
+			upserts = append(upserts, alarm)
+		}
+	}
+	applied, deletedIDs, err := ss.alarms.ApplySync(upserts, deletes)
+	if err != nil {
+		return models.SyncResult{}, err
+	}
+	result.AlarmsAdded, result.AlarmsUpdated, result.AlarmsDeleted = applied.AlarmsAdded, applied.AlarmsUpdated, applied.AlarmsDeleted
+	for _, id := range deletedIDs {
+		if err := ss.profiles.RemoveAlarm(id); err != nil {
+			return result, err
+		}
+	}
+
+	// Saved locations
+	locationWinners := mergeRecords(localLocations, ss.state.LocationTombstones, remoteLocations)
+	adds := make([]models.Location, 0)
+	deletes = deletes[:0]
+	for key, winner := range locationWinners {
+		_, ok := localLocations[key]
+		switch {
+		case winner.Deleted:
+			if ok {
+				deletes = append(deletes, key)
+			}
+		case !ok:
+			var location models.Location
+			if err := json.Unmarshal(winner.Data, &location); err != nil || location.Validate() != nil {
+				continue
+			}
+			adds = append(adds, location)
+		}
+	}
+	applied, err = ss.locations.ApplySync(adds, deletes)
+	if err != nil {
+		return result, err
+	}
+	result.LocationsAdded, result.LocationsDeleted = applied.LocationsAdded, applied.LocationsDeleted
+
+	// Publish the merged state and remember it for the next sync
+	localAlarms, err = alarmRecords(ss.alarms.GetAlarms())
+	if err != nil {
+		return result, err
+	}
+	localLocations, err = locationRecords(ss.locations.GetSavedLocations())
+	if err != nil {
+		return result, err
+	}
+	expired := nowMillis - syncTombstoneTTL.Milliseconds()
+	ss.state.AlarmTombstones = tombstones(alarmWinners, expired)
+	ss.state.LocationTombstones = tombstones(locationWinners, expired)
+	ss.state.Alarms = recordVersions(localAlarms)
+	ss.state.Locations = recordVersions(localLocations)
+
+	file := models.SyncFile{
+		Format:     models.SyncFormat,
+		Version:    models.SyncVersion,
+		DeviceID:   ss.state.DeviceID,
+		DeviceName: deviceName(),
+		Alarms:     sortedRecords(localAlarms, ss.state.AlarmTombstones),
+		Locations:  sortedRecords(localLocations, ss.state.LocationTombstones),
+	}
+	if err := writeSyncFile(filepath.Join(dir, ss.state.DeviceID+".json"), file, nowMillis); err != nil {
+		return result, err
+	}
+	if after, _ := json.Marshal(ss.state); bytes.Equal(before, after) {
+		return result, nil
+	}
+	if err := ss.storage.Save("sync_state", ss.state); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// iqamahConflict reports an iqamah alarm from another device, which is not
+// applied since the mosque it follows only exists there.
+func iqamahConflict(alarm models.Alarm) models.SyncConflict {
+	label := alarm.Label
+	if label == "" {
+		label = alarm.Anchor.DisplayName()
+	}
+	return models.SyncConflict{
+		SyncID: alarm.SyncID,
+		Label:  label,
+		Reason: "follows the iqamah of a mosque on another device, mosques are not synced",
+	}
+}
+
+// alarmRecords converts alarms to sync records keyed by sync ID. Local IDs
+// are left out, they differ between devices, and so is whether the alarm is
+// on, which follows each device's own profiles.
+func alarmRecords(alarms []models.Alarm) (map[string]models.SyncRecord, error) {
+	records := make(map[string]models.SyncRecord, len(alarms))
+	for _, alarm := range alarms {
+		id := alarm.SyncID
+		alarm.ID = 0
+		encoded, err := json.Marshal(alarm)
+		if err != nil {
+			return nil, err
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(encoded, &fields); err != nil {
+			return nil, err
+		}
+		delete(fields, "isActive")
+		data, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+		updatedAt := alarm.UpdatedAt
+		if updatedAt == 0 {
+			updatedAt = alarm.CreatedAt
+		}
+		records[id] = models.SyncRecord{ID: id, UpdatedAt: updatedAt, Data: data}
+	}
+	return records, nil
+}
+
+// locationRecords converts saved locations to sync records keyed by their
+// coordinates. Saved locations are never edited, so CreatedAt is their
+// version.
+func locationRecords(locations []models.Location) (map[string]models.SyncRecord, error) {
+	records := make(map[string]models.SyncRecord, len(locations))
+	for _, location := range locations {
+		key := location.SyncKey()
+		location.ID = 0
+		location.IsCurrent = false
+		data, err := json.Marshal(location)
+		if err != nil {
+			return nil, err
+		}
+		records[key] = models.SyncRecord{ID: key, UpdatedAt: location.CreatedAt, Data: data}
+	}
+	return records, nil
+}
+
+// addTombstones records a tombstone for every item that was present at
+// the last sync but no longer exists locally.
+func addTombstones(existing []models.SyncRecord, known map[string]int64, local map[string]models.SyncRecord, now int64) []models.SyncRecord {
+	for id := range known {
+		if _, ok := local[id]; ok {
+			continue
+		}
+		existing = append(existing, models.SyncRecord{ID: id, UpdatedAt: now, Deleted: true})
+	}
+	return existing
+}
+
+// mergeRecords picks the winning version of every record: the local items,
+// this device's tombstones and the records of every other device compete,
+// and the latest wins.
+func mergeRecords(local map[string]models.SyncRecord, tombstones []models.SyncRecord, remote [][]models.SyncRecord) map[string]models.SyncRecord {
+	winners := make(map[string]models.SyncRecord, len(local))
+	offer := func(r models.SyncRecord) {
+		if r.ID == "" {
+			return
+		}
+		if current, ok := winners[r.ID]; !ok || newerRecord(r, current) {
+			winners[r.ID] = r
+		}
+	}
+	for _, r := range local {
+		offer(r)
+	}
+	for _, r := range tombstones {
+		offer(r)
+	}
+	for _, records := range remote {
+		for _, r := range records {
+			offer(r)
+		}
+	}
+	return winners
+}
+
+// newerRecord checks if a supersedes b. On a tie a deletion wins, so an
+// item deleted and edited at the same instant stays deleted everywhere.
+func newerRecord(a, b models.SyncRecord) bool {
+	if a.UpdatedAt != b.UpdatedAt {
+		return a.UpdatedAt > b.UpdatedAt
+	}
+	return a.Deleted && !b.Deleted
+}
+
+// tombstones returns the winning deletions that have not expired.
+func tombstones(winners map[string]models.SyncRecord, expired int64) []models.SyncRecord {
+	result := make([]models.SyncRecord, 0)
+	for _, r := range winners {
+		if r.Deleted && r.UpdatedAt > expired {
+			result = append(result, models.SyncRecord{ID: r.ID, UpdatedAt: r.UpdatedAt, Deleted: true})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// recordVersions returns the version of each record by ID.
+func recordVersions(records map[string]models.SyncRecord) map[string]int64 {
+	versions := make(map[string]int64, len(records))
+	for id, r := range records {
+		versions[id] = r.UpdatedAt
+	}
+	return versions
+}
+
+// sortedRecords returns live records and tombstones sorted by ID, so the
+// device file only changes when the data does.
+func sortedRecords(live map[string]models.SyncRecord, deleted []models.SyncRecord) []models.SyncRecord {
+	result := make([]models.SyncRecord, 0, len(live)+len(deleted))
+	for _, r := range live {
+		result = append(result, r)
+	}
+	result = append(result, deleted...)
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// readSyncFiles reads the files of all other devices in the sync folder.
+// Files that are not device files, or come from a newer version, are
+// skipped.
+func readSyncFiles(dir, deviceID string) ([]models.SyncFile, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	files := make([]models.SyncFile, 0, len(matches))
+	for _, path := range matches {
+		if filepath.Base(path) == deviceID+".json" {
+			continue
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			continue // Being replaced by the sync tool
+		}
+		var f models.SyncFile
+		if err := json.Unmarshal(raw, &f); err != nil {
+			continue
+		}
+		if f.Format != models.SyncFormat || f.Version > models.SyncVersion || f.DeviceID == deviceID {
+			continue
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// writeSyncFile publishes this device's records, unless the file already
+// holds the same records, so sync tools are not kept busy.
+func writeSyncFile(path string, file models.SyncFile, now int64) error {
+	if raw, err := os.ReadFile(path); err == nil {
+		var current models.SyncFile
+		if json.Unmarshal(raw, &current) == nil {
+			file.WrittenAt = current.WrittenAt
+			if encoded, err := json.MarshalIndent(file, "", "  "); err == nil && bytes.Equal(encoded, raw) {
+				return nil
+			}
+		}
+	}
+
+	file.WrittenAt = now
+	encoded, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, encoded); err != nil {
+		return models.StorageError(err)
+	}
+	return nil
+}
+
+// deviceName returns a name for this device to show in the sync folder.
+func deviceName() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+// newSyncID returns a random identifier for a device or a synced record.
+func newSyncID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand does not fail on supported platforms
+		return hex.EncodeToString([]byte(time.Now().Format(time.RFC3339Nano)))
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"AzanAlarm/internal/models"
+)
+
+// newSyncedTestCore creates a device syncing through dir.
+func newSyncedTestCore(t *testing.T, dir string) *Core {
+	t.Helper()
+	c := newTestCore(t, nil)
+	settings := c.Settings.GetSettings()
+	settings.Sync.Directory = dir
+	if err := c.Settings.SaveSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// TestSyncReportsIqamahAlarms syncs an iqamah alarm, whose mosque only
+// exists on the device that created it.
+func TestSyncReportsIqamahAlarms(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	first := newSyncedTestCore(t, dir)
+	mosque, err := first.Mosques.CreateMosque(models.Mosque{Name: "Central Mosque"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	iqamah := models.NewAlarm(models.Fajr, -5)
+	iqamah.Anchor = models.AlarmAnchor{Type: models.AnchorIqamah, Prayer: models.Fajr, MosqueID: mosque.ID}
+	iqamah, err = first.Alarms.CreateAlarm(iqamah)
+	if err != nil {
+		t.Fatal(err)
+	}
+	createLabelledAlarms(t, first, "plain")
+	if _, err := first.Sync.Sync(now); err != nil {
+		t.Fatal(err)
+	}
+
+	second := newSyncedTestCore(t, dir)
+	for i := 0; i < 2; i++ {
+		result, err := second.Sync.Sync(now.Add(time.Duration(i) * time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Conflicts) != 1 || result.Conflicts[0].SyncID != iqamah.SyncID {
+			t.Errorf("sync %d: got conflicts %+v, want the iqamah alarm", i+1, result.Conflicts)
+		}
+	}
+	if alarms := second.Alarms.GetAlarms(); len(alarms) != 1 || alarms[0].Label != "plain" {
+		t.Errorf("got alarms %+v, want only the plain alarm", alarms)
+	}
+
+	// Skipping the alarm must not delete it on the device that has it
+	if _, err := first.Sync.Sync(now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if first.Alarms.GetAlarm(iqamah.ID) == nil {
+		t.Error("the iqamah alarm was deleted on its own device")
+	}
+}
+
+// nextMilli waits until the clock has moved on by a millisecond, so an
+// edit is newer than the one before it.
+func nextMilli() {
+	start := time.Now().UnixMilli()
+	for time.Now().UnixMilli() == start {
+		time.Sleep(100 * time.Microsecond)
+	}
+}
+
+// syncAll syncs each device in turn.
+func syncAll(t *testing.T, now time.Time, devices ...*Core) {
+	t.Helper()
+	for _, c := range devices {
+		if _, err := c.Sync.Sync(now); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// alarmBySyncID returns the alarm with the sync ID on a device, or nil.
+func alarmBySyncID(c *Core, syncID string) *models.Alarm {
+	for _, alarm := range c.Alarms.GetAlarms() {
+		if alarm.SyncID == syncID {
+			return &alarm
+		}
+	}
+	return nil
+}
+
+// TestSyncKeepsActivationPerDevice switches an alarm off on one device,
+// e.g. by activating a profile, and edits it on the other.
+func TestSyncKeepsActivationPerDevice(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	first, second := newSyncedTestCore(t, dir), newSyncedTestCore(t, dir)
+	alarm := createLabelledAlarms(t, first, "a")[0]
+	syncAll(t, now, first, second)
+
+	if err := first.Alarms.SetActiveStates(map[int]bool{alarm.ID: false}); err != nil {
+		t.Fatal(err)
+	}
+	syncAll(t, now.Add(time.Minute), first, second)
+	if got := alarmBySyncID(second, alarm.SyncID); got == nil || !got.IsActive {
+		t.Fatalf("got %+v on the second device, want the alarm still on", got)
+	}
+
+	nextMilli()
+	edited := *alarmBySyncID(second, alarm.SyncID)
+	edited.Label = "edited"
+	if err := second.Alarms.UpdateAlarm(edited); err != nil {
+		t.Fatal(err)
+	}
+	syncAll(t, now.Add(2*time.Minute), second, first)
+	if got := first.Alarms.GetAlarm(alarm.ID); got == nil || got.Label != "edited" || got.IsActive {
+		t.Errorf("got %+v on the first device, want the edit with the alarm still off", got)
+	}
+}
+
+// relabel edits the label of the alarm with the sync ID on a device.
+func relabel(t *testing.T, c *Core, syncID, label string) {
+	t.Helper()
+	alarm := alarmBySyncID(c, syncID)
+	if alarm == nil {
+		t.Fatalf("alarm %s not found", syncID)
+	}
+	alarm.Label = label
+	if err := c.Alarms.UpdateAlarm(*alarm); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSyncKeepsEditsToDifferentAlarms(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	first, second := newSyncedTestCore(t, dir), newSyncedTestCore(t, dir)
+	alarms := createLabelledAlarms(t, first, "a", "b")
+	syncAll(t, now, first, second)
+
+	nextMilli()
+	relabel(t, first, alarms[0].SyncID, "a edited")
+	relabel(t, second, alarms[1].SyncID, "b edited")
+	syncAll(t, now.Add(time.Minute), first, second, first)
+
+	for _, c := range []*Core{first, second} {
+		if got := alarmBySyncID(c, alarms[0].SyncID); got == nil || got.Label != "a edited" {
+			t.Errorf("got %+v, want a edited", got)
+		}
+		if got := alarmBySyncID(c, alarms[1].SyncID); got == nil || got.Label != "b edited" {
+			t.Errorf("got %+v, want b edited", got)
+		}
+	}
+}
+
+func TestSyncNewerEditWins(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	first, second := newSyncedTestCore(t, dir), newSyncedTestCore(t, dir)
+	alarm := createLabelledAlarms(t, first, "a")[0]
+	syncAll(t, now, first, second)
+
+	nextMilli()
+	relabel(t, first, alarm.SyncID, "older")
+	nextMilli()
+	relabel(t, second, alarm.SyncID, "newer")
+	// The older edit syncs last, and must not win
+	syncAll(t, now.Add(time.Minute), second, first, second)
+
+	for _, c := range []*Core{first, second} {
+		if got := alarmBySyncID(c, alarm.SyncID); got == nil || got.Label != "newer" {
+			t.Errorf("got %+v, want the newer edit", got)
+		}
+	}
+}
+
+func TestSyncDeletesAlarms(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	first, second := newSyncedTestCore(t, dir), newSyncedTestCore(t, dir)
+	alarms := createLabelledAlarms(t, first, "a", "b")
+	syncAll(t, now, first, second)
+
+	if err := first.Alarms.DeleteAlarm(alarms[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	syncAll(t, now.Add(time.Minute), first)
+	if tombstones := first.Sync.state.AlarmTombstones; len(tombstones) != 1 || tombstones[0].ID != alarms[0].SyncID {
+		t.Fatalf("got tombstones %+v, want one for a", tombstones)
+	}
+
+	result, err := second.Sync.Sync(now.Add(2 * time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.AlarmsDeleted != 1 {
+		t.Errorf("deleted %d alarms, want 1", result.AlarmsDeleted)
+	}
+	if got := second.Alarms.GetAlarms(); len(got) != 1 || got[0].Label != "b" {
+		t.Errorf("got alarms %+v, want only b", got)
+	}
+}
+
+// TestSyncDeletedAlarmStaysDeleted syncs a device that still has an alarm
+// deleted elsewhere since it last synced.
+func TestSyncDeletedAlarmStaysDeleted(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	first, second, stale := newSyncedTestCore(t, dir), newSyncedTestCore(t, dir), newSyncedTestCore(t, dir)
+	alarms := createLabelledAlarms(t, first, "a", "b")
+	syncAll(t, now, first, second, stale)
+
+	if err := first.Alarms.DeleteAlarm(alarms[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	syncAll(t, now.Add(time.Minute), first, second)
+	syncAll(t, now.Add(time.Hour), stale, first, second)
+
+	for name, c := range map[string]*Core{"first": first, "second": second, "stale": stale} {
+		if got := c.Alarms.GetAlarms(); len(got) != 1 || got[0].Label != "b" {
+			t.Errorf("%s device: got alarms %+v, want only b", name, got)
+		}
+	}
+}
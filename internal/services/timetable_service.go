@@ -0,0 +1,653 @@
+// Package services contains business logic for the AzanAlarm application.
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"AzanAlarm/internal/models"
+)
+
+// suspiciousDeltaMinutes is how far a time may stray from the typical
+// difference for its prayer before the diff flags it as a likely typo.
+const suspiciousDeltaMinutes = 10
+
+// defaultTimeLayouts are tried in order when a mapping has no time format.
+var defaultTimeLayouts = []string{"15:04", "15:04:05", "3:04 PM", "3:04PM", "3:04 pm", "3:04pm"}
+
+// clockRange is the span of hours, in 24-hour time, a prayer can plausibly
+// start at anywhere people live, allowing for daylight saving time and wide
+// time zones. A wrapping range, such as Isha's, continues past midnight.
+type clockRange struct {
+	from, to int // Inclusive hours
+}
+
+// prayerClockRanges catch 12-hour times without AM or PM, such as a
+// Maghrib of 7:45, which would otherwise be read as morning times.
+var prayerClockRanges = map[models.Prayer]clockRange{
+	models.Fajr:    {0, 10},
+	models.Dhuhr:   {10, 15},
+	models.Asr:     {12, 19},
+	models.Maghrib: {14, 23},
+	models.Isha:    {15, 2},
+}
+
+// contains checks if a time of day falls within the range.
+func (r clockRange) contains(t time.Time) bool {
+	if r.from <= r.to {
+		return t.Hour() >= r.from && t.Hour() <= r.to
+	}
+	return t.Hour() >= r.from || t.Hour() <= r.to
+}
+
+// minutesAfterMidnight returns the minutes since midnight, counting times
+// past midnight in a wrapping range as belonging to the day before.
+func (r clockRange) minutesAfterMidnight(t time.Time) int {
+	minutes := t.Hour()*60 + t.Minute()
+	if r.from > r.to && t.Hour() <= r.to {
+		minutes += 24 * 60
+	}
+	return minutes
+}
+
+// TimetableService imports official mosque timetables, which take
+// precedence over calculated prayer times for their location. It is safe
+// for concurrent use.
+type TimetableService struct {
+	storage    *StorageService
+	calculator *PrayerCalculator
+	settings   *SettingsService
+	mu         sync.RWMutex
+	timetables []models.Timetable
+	nextID     int
+}
+
+// NewTimetableService creates a new TimetableService instance.
+func NewTimetableService(storage *StorageService, calculator *PrayerCalculator, settings *SettingsService) *TimetableService {
+	ts := &TimetableService{
+		storage:    storage,
+		calculator: calculator,
+		settings:   settings,
+		timetables: []models.Timetable{},
+		nextID:     1,
+	}
+
+	// Load existing timetables
+	var savedTimetables []models.Timetable
+	if err := storage.Load("timetables", &savedTimetables); err == nil {
+		ts.timetables = savedTimetables
+		for _, t := range ts.timetables {
+			if t.ID >= ts.nextID {
+				ts.nextID = t.ID + 1
+			}
+		}
+	}
+
+	return ts
+}
+
+// Reload replaces the timetables with those in storage, e.g. after the
+// file was replaced outside the app.
+func (ts *TimetableService) Reload() error {
+	var timetables []models.Timetable
+	if err := ts.storage.Load("timetables", &timetables); err != nil {
+		return err
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	ts.timetables = timetables
+	for _, t := range ts.timetables {
+		if t.ID >= ts.nextID {
+			ts.nextID = t.ID + 1
+		}
+	}
+	return nil
+}
+
+// GetTimetables returns a copy of all imported timetables.
+func (ts *TimetableService) GetTimetables() []models.Timetable {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	result := make([]models.Timetable, len(ts.timetables))
+	for i, t := range ts.timetables {
+		result[i] = t.Clone()
+	}
+	return result
+}
+
+// GetTimetable returns a copy of a specific timetable by ID.
+func (ts *TimetableService) GetTimetable(id int) *models.Timetable {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	if i := ts.indexOf(id); i >= 0 {
+		timetable := ts.timetables[i].Clone()
+		return &timetable
+	}
+	return nil
+}
+
+// DeleteTimetable removes an imported timetable.
+func (ts *TimetableService) DeleteTimetable(id int) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.indexOf(id) < 0 {
+		return models.NotFoundError("timetable %d not found", id)
+	}
+	newTimetables := make([]models.Timetable, 0, len(ts.timetables))
+	for _, t := range ts.timetables {
+		if t.ID != id {
+			newTimetables = append(newTimetables, t)
+		}
+	}
+	ts.timetables = newTimetables
+	return ts.save()
+}
+
+// Preview parses a timetable file and compares it with calculated times
+// for the location without saving anything.
+func (ts *TimetableService) Preview(path string, mapping models.TimetableMapping, location models.Location) (models.TimetableImportResult, error) {
+	entries, parseErrors, err := ts.parseFile(path, mapping)
+	if err != nil {
+		return models.TimetableImportResult{}, err
+	}
+	return ts.buildResult(entries, parseErrors, location), nil
+}
+
+// Import parses a timetable file and saves the rows that could be read for
+// the location. Rows with errors are skipped and reported in the result.
+func (ts *TimetableService) Import(path, name string, mapping models.TimetableMapping, location models.Location) (models.TimetableImportResult, error) {
+	entries, parseErrors, err := ts.parseFile(path, mapping)
+	if err != nil {
+		return models.TimetableImportResult{}, err
+	}
+	result := ts.buildResult(entries, parseErrors, location)
+	if len(entries) == 0 {
+		return result, models.ValidationError("no timetable rows could be read")
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = location.Name
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	timetable := models.Timetable{
+		ID:           ts.nextID,
+		Name:         name,
+		LocationName: location.Name,
+		Latitude:     location.Latitude,
+		Longitude:    location.Longitude,
+		Entries:      entries,
+		ImportedAt:   time.Now().UnixMilli(),
+	}
+	ts.nextID++
+	ts.timetables = append(ts.timetables, timetable)
+	if err := ts.save(); err != nil {
+		return result, err
+	}
+
+	result.TimetableID = timetable.ID
+	return result, nil
+}
+
+// stageImport computes the timetables after restoring a backup, without
+// changing anything until the returned step is committed. In merge mode,
+// timetables with the name and location of an existing one are skipped and
+// the rest get new IDs.
+func (ts *TimetableService) stageImport(timetables []models.Timetable, mode models.RestoreMode) (restoreStep, error) {
+	counts := models.RestoreCounts{Section: "timetables"}
+	for i := range timetables {
+		if err := timetables[i].Validate(); err != nil {
+			return restoreStep{}, err
+		}
+	}
+
+	// The restored timetables are built in a copy of the service
+	ts.mu.RLock()
+	staged := &TimetableService{nextID: ts.nextID}
+	if mode == models.RestoreReplace {
+		counts.Removed = len(ts.timetables)
+		staged.timetables = make([]models.Timetable, 0, len(timetables))
+		staged.nextID = 1
+		for _, t := range timetables {
+			if t.ID >= staged.nextID {
+				staged.nextID = t.ID + 1
+			}
+		}
+	} else {
+		staged.timetables = append(make([]models.Timetable, 0, len(ts.timetables)+len(timetables)), ts.timetables...)
+	}
+	ts.mu.RUnlock()
+
+	for _, t := range timetables {
+		if mode == models.RestoreMerge && staged.hasTimetable(t) {
+			counts.Unchanged++
+			continue
+		}
+		// Backup IDs are kept on replace, unless they clash
+		if mode == models.RestoreMerge || t.ID <= 0 || staged.indexOf(t.ID) >= 0 {
+			t.ID = staged.nextID
+			staged.nextID++
+		}
+		staged.timetables = append(staged.timetables, t.Clone())
+		counts.Added++
+	}
+
+	var previous []models.Timetable
+	var previousNextID int
+	return restoreStep{
+		counts: counts,
+		commit: func() error {
+			ts.mu.Lock()
+			defer ts.mu.Unlock()
+
+			previous, previousNextID = ts.timetables, ts.nextID
+			ts.timetables, ts.nextID = staged.timetables, staged.nextID
+			if err := ts.save(); err != nil {
+				ts.timetables, ts.nextID = previous, previousNextID
+				return err
+			}
+			return nil
+		},
+		undo: func() error {
+			ts.mu.Lock()
+			defer ts.mu.Unlock()
+
+			ts.timetables, ts.nextID = previous, previousNextID
+			return ts.save()
+		},
+	}, nil
+}
+
+// Lookup returns the official times for a location and date. When several
+// timetables cover the date, the most recently imported one wins.
+func (ts *TimetableService) Lookup(latitude, longitude float64, date time.Time) (models.TimetableEntry, bool) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	key := date.Format(dateLayout)
+	for i := len(ts.timetables) - 1; i >= 0; i-- {
+		t := &ts.timetables[i]
+		if !t.Covers(latitude, longitude) {
+			continue
+		}
+		for _, e := range t.Entries {
+			if e.Date == key {
+				return e, true
+			}
+		}
+	}
+	return models.TimetableEntry{}, false
+}
+
+// ApplyTo replaces calculated times with official ones where available.
+func (ts *TimetableService) ApplyTo(times models.PrayerTimes, latitude, longitude float64, date time.Time) models.PrayerTimes {
+	entry, ok := ts.Lookup(latitude, longitude, date)
+	if !ok {
+		return times
+	}
+
+	result := times
+	for _, prayer := range models.AllPrayers() {
+		official, ok := officialTime(entry, prayer, date)
+		if !ok {
+			continue
+		}
+		result.SetTime(prayer, official.Format(time.RFC3339))
+	}
+	return result
+}
+
+// buildResult summarises parsed entries and diffs them against the
+// calculated times for the location.
+func (ts *TimetableService) buildResult(entries []models.TimetableEntry, parseErrors []models.TimetableParseError, location models.Location) models.TimetableImportResult {
+	result := models.TimetableImportResult{
+		Imported: len(entries),
+		Errors:   parseErrors,
+		Diffs:    make([]models.TimetableDiff, 0),
+	}
+	if len(entries) > 0 {
+		result.From = entries[0].Date
+		result.To = entries[len(entries)-1].Date
+	}
+
+	settings := ts.settings.GetSettings()
+	deltas := make(map[models.Prayer][]int)
+	for _, e := range entries {
+		date, err := time.ParseInLocation(dateLayout, e.Date, time.Local)
+		if err != nil {
+			continue
+		}
+		calculated := ts.calculator.Calculate(
+			location.Latitude,
+			location.Longitude,
+			date,
+			settings.CalculationMethod,
+			settings.JuristicMethod,
+			timezoneOffsetFor(date),
+		)
+		for _, prayer := range models.AllPrayers() {
+			official, ok := officialTime(e, prayer, date)
+			if !ok {
+				continue
+			}
+			calc, ok := calculated.ParseTime(prayer)
+			if !ok {
+				continue
+			}
+			delta := int(official.Sub(calc).Minutes())
+			deltas[prayer] = append(deltas[prayer], delta)
+			result.Diffs = append(result.Diffs, models.TimetableDiff{
+				Date:         e.Date,
+				Prayer:       prayer,
+				Official:     e.GetTime(prayer),
+				Calculated:   calc.Format("15:04"),
+				DeltaMinutes: delta,
+			})
+		}
+	}
+
+	// Official timetables differ from calculation by a steady amount, so a
+	// time far from the usual difference for its prayer is likely a typo.
+	medians := make(map[models.Prayer]int)
+	for prayer, values := range deltas {
+		medians[prayer] = medianInt(values)
+	}
+	for i := range result.Diffs {
+		d := &result.Diffs[i]
+		if abs(d.DeltaMinutes-medians[d.Prayer]) > suspiciousDeltaMinutes {
+			d.Suspicious = true
+		}
+	}
+
+	return result
+}
+
+// parseFile opens and parses a timetable CSV file.
+func (ts *TimetableService) parseFile(path string, mapping models.TimetableMapping) ([]models.TimetableEntry, []models.TimetableParseError, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil, models.NotFoundError("timetable file %q not found", path)
+	} else if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	entries, parseErrors, err := parseTimetableCSV(f, mapping)
+	if err != nil {
+		return nil, nil, models.ValidationError("%v", err)
+	}
+	return entries, parseErrors, nil
+}
+
+// parseTimetableCSV reads timetable rows using the column mapping. Rows that
+// cannot be read are reported with their line number and skipped. The
+// returned entries are sorted by date, later rows replacing earlier ones.
+func parseTimetableCSV(r io.Reader, mapping models.TimetableMapping) ([]models.TimetableEntry, []models.TimetableParseError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.LazyQuotes = true
+	if mapping.Delimiter != "" {
+		delimiter, size := utf8.DecodeRuneInString(mapping.Delimiter)
+		if size != len(mapping.Delimiter) {
+			return nil, nil, fmt.Errorf("delimiter must be a single character, got %q", mapping.Delimiter)
+		}
+		reader.Comma = delimiter
+	}
+
+	dateFormat := mapping.DateFormat
+	if dateFormat == "" {
+		dateFormat = dateLayout
+	}
+	timeLayouts := defaultTimeLayouts
+	if mapping.TimeFormat != "" {
+		timeLayouts = []string{mapping.TimeFormat}
+	}
+
+	var header []string
+	if mapping.HasHeader {
+		record, err := reader.Read()
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading header: %w", err)
+		}
+		header = record
+	}
+
+	dateIndex, err := resolveColumn(mapping.DateColumn, header)
+	if err != nil {
+		return nil, nil, fmt.Errorf("date column: %w", err)
+	}
+	prayerIndexes := make(map[models.Prayer]int)
+	for _, prayer := range models.AllPrayers() {
+		column := mapping.PrayerColumn(prayer)
+		if column == "" {
+			continue
+		}
+		index, err := resolveColumn(column, header)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s column: %w", prayer.DisplayName(), err)
+		}
+		prayerIndexes[prayer] = index
+	}
+	if len(prayerIndexes) == 0 {
+		return nil, nil, errors.New("no prayer columns are mapped")
+	}
+
+	byDate := make(map[string]models.TimetableEntry)
+	parseErrors := make([]models.TimetableParseError, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				line = parseErr.Line
+			}
+			parseErrors = append(parseErrors, models.TimetableParseError{Line: line, Message: err.Error()})
+			continue
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+
+		if dateIndex >= len(record) {
+			parseErrors = append(parseErrors, models.TimetableParseError{Line: line, Column: mapping.DateColumn, Message: "missing date"})
+			continue
+		}
+		date, err := time.Parse(dateFormat, strings.TrimSpace(record[dateIndex]))
+		if err != nil {
+			parseErrors = append(parseErrors, models.TimetableParseError{
+				Line:    line,
+				Column:  mapping.DateColumn,
+				Message: fmt.Sprintf("invalid date %q, expected format %s", record[dateIndex], dateFormat),
+			})
+			continue
+		}
+
+		entry := models.TimetableEntry{Date: date.Format(dateLayout)}
+		valid := true
+		previous, previousPrayer := -1, models.Prayer("")
+		for _, prayer := range models.AllPrayers() {
+			index, ok := prayerIndexes[prayer]
+			if !ok {
+				continue
+			}
+			column := mapping.PrayerColumn(prayer)
+			if index >= len(record) || strings.TrimSpace(record[index]) == "" {
+				parseErrors = append(parseErrors, models.TimetableParseError{Line: line, Column: column, Message: "missing " + prayer.DisplayName() + " time"})
+				valid = false
+				continue
+			}
+			t, ok := parseClock(strings.TrimSpace(record[index]), timeLayouts)
+			if !ok {
+				parseErrors = append(parseErrors, models.TimetableParseError{
+					Line:    line,
+					Column:  column,
+					Message: fmt.Sprintf("invalid %s time %q", prayer.DisplayName(), record[index]),
+				})
+				valid = false
+				continue
+			}
+			clock := prayerClockRanges[prayer]
+			if !clock.contains(t) {
+				parseErrors = append(parseErrors, models.TimetableParseError{
+					Line:   line,
+					Column: column,
+					Message: fmt.Sprintf("%s time %q is outside %02d:00-%02d:59, 12-hour times need AM or PM",
+						prayer.DisplayName(), record[index], clock.from, clock.to),
+				})
+				valid = false
+				continue
+			}
+			minutes := clock.minutesAfterMidnight(t)
+			if minutes <= previous {
+				parseErrors = append(parseErrors, models.TimetableParseError{
+					Line:    line,
+					Column:  column,
+					Message: fmt.Sprintf("%s time %q is not after %s", prayer.DisplayName(), record[index], previousPrayer.DisplayName()),
+				})
+				valid = false
+				continue
+			}
+			previous, previousPrayer = minutes, prayer
+			entry.SetTime(prayer, t.Format("15:04"))
+		}
+		if valid {
+			byDate[entry.Date] = entry
+		}
+	}
+
+	entries := make([]models.TimetableEntry, 0, len(byDate))
+	for _, e := range byDate {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Date < entries[j].Date
+	})
+	return entries, parseErrors, nil
+}
+
+// resolveColumn finds the index of a column given as a header name or a
+// 1-based column number.
+func resolveColumn(column string, header []string) (int, error) {
+	column = strings.TrimSpace(column)
+	if column == "" {
+		return 0, errors.New("not mapped")
+	}
+	if n, err := strconv.Atoi(column); err == nil {
+		if n < 1 {
+			return 0, fmt.Errorf("column number must start at 1, got %d", n)
+		}
+		return n - 1, nil
+	}
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), column) {
+			return i, nil
+		}
+	}
+	if header == nil {
+		return 0, fmt.Errorf("%q must be a column number when the file has no header", column)
+	}
+	return 0, fmt.Errorf("no column named %q", column)
+}
+
+// officialTime returns the official time of a prayer on the given date.
+func officialTime(entry models.TimetableEntry, prayer models.Prayer, date time.Time) (time.Time, bool) {
+	value := entry.GetTime(prayer)
+	if value == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	day := date.Day()
+	if clock := prayerClockRanges[prayer]; clock.from > clock.to && t.Hour() <= clock.to {
+		day++ // An Isha after midnight belongs to the evening before
+	}
+	return time.Date(date.Year(), date.Month(), day, t.Hour(), t.Minute(), 0, 0, time.Local), true
+}
+
+// parseClock parses a time of day with the first matching layout.
+func parseClock(value string, layouts []string) (time.Time, bool) {
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// isBlankRecord checks if every field of a CSV record is empty.
+func isBlankRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// medianInt returns the median of a non-empty slice of integers.
+func medianInt(values []int) int {
+	sorted := make([]int, len(values))
+	copy(sorted, values)
+	sort.Ints(sorted)
+	return sorted[len(sorted)/2]
+}
+
+// abs returns the absolute value of an integer.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// indexOf returns the position of a timetable in the list, or -1. The
+// caller must hold the lock.
+func (ts *TimetableService) indexOf(id int) int {
+	for i := range ts.timetables {
+		if ts.timetables[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// hasTimetable checks if a timetable with the same name was imported for
+// the same location. The caller must hold the lock.
+func (ts *TimetableService) hasTimetable(timetable models.Timetable) bool {
+	for i := range ts.timetables {
+		t := &ts.timetables[i]
+		if strings.EqualFold(t.Name, timetable.Name) && t.Latitude == timetable.Latitude && t.Longitude == timetable.Longitude {
+			return true
+		}
+	}
+	return false
+}
+
+// save persists timetables to storage. The caller must hold the lock.
+func (ts *TimetableService) save() error {
+	return ts.storage.Save("timetables", ts.timetables)
+}
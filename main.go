@@ -2,6 +2,11 @@ package main
 
 import (
 	"embed"
+	"flag"
+	"os"
+
+	"AzanAlarm/internal/cli"
+	"AzanAlarm/internal/services"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -12,8 +17,17 @@ import (
 var assets embed.FS
 
 func main() {
+	dataDir := flag.String("data-dir", "", "directory for application data (overrides "+services.DataDirEnv+")")
+	flag.Usage = func() { cli.Usage(flag.CommandLine.Output()) }
+	flag.Parse()
+
+	// Run a command without starting the window, e.g. "azanalarm next"
+	if flag.NArg() > 0 {
+		os.Exit(cli.Run(*dataDir, flag.Args(), os.Stdout, os.Stderr))
+	}
+
 	// Create an instance of the app structure
-	app := NewApp()
+	app := NewApp(*dataDir)
 
 	// Create application with options
 	err := wails.Run(&options.App{
@@ -25,6 +39,9 @@ func main() {
 		},
 		BackgroundColour: &options.RGBA{R: 27, G: 38, B: 54, A: 1},
 		OnStartup:        app.startup,
+		OnDomReady:       app.domReady,
+		OnShutdown:       app.shutdown,
+		ErrorFormatter:   formatError,
 		Bind: []interface{}{
 			app,
 		},